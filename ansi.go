@@ -0,0 +1,376 @@
+package blocked
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ColorMode is a terminal color mode.
+type ColorMode int
+
+// Color modes.
+const (
+	// ColorNone is no color.
+	ColorNone ColorMode = iota
+	// Color16 is the standard 16 color ANSI palette.
+	Color16
+	// Color256 is the xterm 256 color palette.
+	Color256
+	// ColorTrue is 24-bit truecolor.
+	ColorTrue
+)
+
+// String satisfies the [fmt.Stringer] interface.
+func (mode ColorMode) String() string {
+	switch mode {
+	case ColorNone:
+		return "None"
+	case Color16:
+		return "16"
+	case Color256:
+		return "256"
+	case ColorTrue:
+		return "True"
+	}
+	return ""
+}
+
+// EncodeColor encodes the bitmap to the writer using the block type, wrapping
+// each line in ANSI SGR escapes for the foreground (set) and background (unset)
+// colors using the color mode. A nil color uses the terminal's default color.
+// Each line is terminated with a reset, so the output can be safely displayed
+// in pagers (such as `less -R`).
+func (img Bitmap) EncodeColor(w io.Writer, typ Type, mode ColorMode, fg, bg color.Color) error {
+	if mode == ColorNone {
+		return img.Encode(w, typ)
+	}
+	s := &sgr{mode: mode}
+	sw := &sgrWriter{w: w, prefix: s.set(nil, fg, bg)}
+	if err := img.Encode(sw, typ); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// sgrWriter wraps a writer, writing a prefix to the start of each non-empty
+// line, and a reset to the end of each prefixed line.
+type sgrWriter struct {
+	w      io.Writer
+	prefix []byte
+	open   bool
+}
+
+// Write satisfies the [io.Writer] interface.
+func (w *sgrWriter) Write(buf []byte) (int, error) {
+	n, out := len(buf), make([]byte, 0, len(buf)+2*len(w.prefix))
+	for len(buf) != 0 {
+		line, rest, ok := bytes.Cut(buf, nl)
+		if len(line) != 0 && len(w.prefix) != 0 && !w.open {
+			out, w.open = append(out, w.prefix...), true
+		}
+		out = append(out, line...)
+		if ok {
+			if w.open {
+				out, w.open = append(out, sgrReset...), false
+			}
+			out = append(out, '\n')
+		}
+		buf = rest
+	}
+	if _, err := w.w.Write(out); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close resets any open line.
+func (w *sgrWriter) Close() error {
+	if !w.open {
+		return nil
+	}
+	w.open = false
+	_, err := w.w.Write(sgrReset)
+	return err
+}
+
+// sgr tracks the ANSI SGR color state of a terminal, emitting only the
+// changes to the foreground and background colors.
+type sgr struct {
+	mode   ColorMode
+	fg, bg string
+}
+
+// set appends the escape for the changes to the foreground and background
+// colors to buf.
+func (s *sgr) set(buf []byte, fg, bg color.Color) []byte {
+	var params []string
+	if v := s.param(fg, false); v != s.fg {
+		params, s.fg = append(params, v), v
+	}
+	if v := s.param(bg, true); v != s.bg {
+		params, s.bg = append(params, v), v
+	}
+	if len(params) == 0 {
+		return buf
+	}
+	buf = append(buf, "\x1b["...)
+	for i, v := range params {
+		if i != 0 {
+			buf = append(buf, ';')
+		}
+		buf = append(buf, v...)
+	}
+	return append(buf, 'm')
+}
+
+// reset appends a reset to buf, when colors have been set.
+func (s *sgr) reset(buf []byte) []byte {
+	if s.fg == "" && s.bg == "" {
+		return buf
+	}
+	s.fg, s.bg = "", ""
+	return append(buf, sgrReset...)
+}
+
+// param returns the SGR parameter for the color.
+func (s *sgr) param(c color.Color, bg bool) string {
+	if c == nil {
+		// a default color is only emitted after a color has been set
+		switch {
+		case bg && s.bg != "":
+			return "49"
+		case !bg && s.fg != "":
+			return "39"
+		}
+		return ""
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	switch s.mode {
+	case Color16:
+		i := ansi16Index(n)
+		switch {
+		case i < 8 && bg:
+			return strconv.Itoa(40 + i)
+		case i < 8:
+			return strconv.Itoa(30 + i)
+		case bg:
+			return strconv.Itoa(100 + i - 8)
+		}
+		return strconv.Itoa(90 + i - 8)
+	case Color256:
+		if bg {
+			return "48;5;" + strconv.Itoa(ansi256Index(n))
+		}
+		return "38;5;" + strconv.Itoa(ansi256Index(n))
+	}
+	v := strconv.Itoa(int(n.R)) + ";" + strconv.Itoa(int(n.G)) + ";" + strconv.Itoa(int(n.B))
+	if bg {
+		return "48;2;" + v
+	}
+	return "38;2;" + v
+}
+
+// ansi16 is the xterm default 16 color palette.
+var ansi16 = [16]color.NRGBA{
+	{0x00, 0x00, 0x00, 0xff}, {0xcd, 0x00, 0x00, 0xff},
+	{0x00, 0xcd, 0x00, 0xff}, {0xcd, 0xcd, 0x00, 0xff},
+	{0x00, 0x00, 0xee, 0xff}, {0xcd, 0x00, 0xcd, 0xff},
+	{0x00, 0xcd, 0xcd, 0xff}, {0xe5, 0xe5, 0xe5, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff}, {0xff, 0x00, 0x00, 0xff},
+	{0x00, 0xff, 0x00, 0xff}, {0xff, 0xff, 0x00, 0xff},
+	{0x5c, 0x5c, 0xff, 0xff}, {0xff, 0x00, 0xff, 0xff},
+	{0x00, 0xff, 0xff, 0xff}, {0xff, 0xff, 0xff, 0xff},
+}
+
+// ansi16Index returns the index of the closest color in the 16 color palette.
+func ansi16Index(c color.NRGBA) int {
+	k, d := 0, -1
+	for i, p := range ansi16 {
+		if v := dist(c, p); d == -1 || v < d {
+			k, d = i, v
+		}
+	}
+	return k
+}
+
+// ansi256Index returns the index of the closest color in the xterm 256 color
+// palette's 6x6x6 color cube or grayscale ramp.
+func ansi256Index(c color.NRGBA) int {
+	levels := [6]uint8{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+	level := func(v uint8) int {
+		k := 0
+		for i, l := range levels {
+			if absDiff(v, l) < absDiff(v, levels[k]) {
+				k = i
+			}
+		}
+		return k
+	}
+	r, g, b := level(c.R), level(c.G), level(c.B)
+	cube := color.NRGBA{levels[r], levels[g], levels[b], 0xff}
+	// grayscale ramp is 8, 18, ..., 238
+	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
+	i := min(max((avg-3)/10, 0), 23)
+	v := uint8(8 + 10*i)
+	if gray := (color.NRGBA{v, v, v, 0xff}); dist(c, gray) < dist(c, cube) {
+		return 232 + i
+	}
+	return 16 + 36*r + 6*g + b
+}
+
+// dist returns the squared distance between colors.
+func dist(a, b color.NRGBA) int {
+	r, g, bl := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+	return r*r + g*g + bl*bl
+}
+
+// absDiff returns the absolute difference of a and b.
+func absDiff(a, b uint8) uint8 {
+	if a < b {
+		return b - a
+	}
+	return a - b
+}
+
+// sgrReset is the SGR reset escape.
+var sgrReset = []byte("\x1b[0m")
+
+// EncodeImage encodes the image to the writer as colored blocks of the block
+// type using ANSI SGR escapes in the color mode, similar to chafa.
+//
+// For each block, the block's rune and the foreground and background colors
+// are chosen to minimize the (squared) color error over the block's pixels in
+// the image. The block type must be a [Type.Contiguous] block type, such as
+// [Halves], [Quads], [Sextants] or [Octants].
+func EncodeImage(w io.Writer, src image.Image, typ Type, mode ColorMode) error {
+	if !typ.Contiguous() {
+		return fmt.Errorf("block type %s is not contiguous", typ)
+	}
+	syms := typ.runeMap()
+	cw, ch, n := typ.Width(), typ.Height(), 1
+	if cw == 0 {
+		cw, n = 1, 2
+	}
+	b := src.Bounds()
+	s, v := &sgr{mode: mode}, make([]byte, 4)
+	var buf []byte
+	for y := b.Min.Y; y < b.Max.Y; y += ch {
+		buf = buf[:0]
+		if y != b.Min.Y {
+			buf = append(buf, '\n')
+		}
+		// the previous colors are retained when not visible in a block
+		var fg, bg color.Color
+		for x := b.Min.X; x < b.Max.X; x += cw {
+			m, valid, f, g := bestCell(src, image.Rect(x, y, x+cw, y+ch).Intersect(b), cw)
+			switch {
+			case m == 0:
+				bg = g
+			case m == valid:
+				fg = f
+			default:
+				// the inverse has the same error, and may not need escapes
+				if matches(fg, bg, g, f) > matches(fg, bg, f, g) {
+					m, f, g = m^valid, g, f
+				}
+				fg, bg = f, g
+			}
+			if mode != ColorNone {
+				buf = s.set(buf, fg, bg)
+			}
+			r := v[:utf8.EncodeRune(v, syms[m])]
+			for range n {
+				buf = append(buf, r...)
+			}
+		}
+		buf = s.reset(buf)
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bestCell returns the mask and foreground and background colors minimizing
+// the squared color error for the pixels in r, in a block of width w, along
+// with the mask of the pixels in r.
+func bestCell(src image.Image, r image.Rectangle, w int) (uint8, uint8, color.NRGBA, color.NRGBA) {
+	var px [8][3]int
+	var valid uint8
+	var total [3]int
+	sq := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			i := (y-r.Min.Y)*w + x - r.Min.X
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			px[i] = [3]int{int(c.R), int(c.G), int(c.B)}
+			valid |= 1 << i
+			for j, v := range px[i] {
+				total[j] += v
+				sq += v * v
+			}
+		}
+	}
+	count := bits.OnesCount8(valid)
+	var best uint8
+	bestErr := -1.0
+	var bestSum [3]int
+	for m := 0; m <= int(valid); m++ {
+		mask := uint8(m)
+		if mask&^valid != 0 {
+			continue
+		}
+		var sum [3]int
+		for i := range 8 {
+			if mask&(1<<i) != 0 {
+				for j, v := range px[i] {
+					sum[j] += v
+				}
+			}
+		}
+		// sse = sum(p²) - |S1|²/n1 - |S0|²/n0
+		n1 := bits.OnesCount8(mask)
+		n0 := count - n1
+		e := float64(sq)
+		for j := range 3 {
+			if n1 != 0 {
+				e -= float64(sum[j]*sum[j]) / float64(n1)
+			}
+			if n0 != 0 {
+				e -= float64((total[j]-sum[j])*(total[j]-sum[j])) / float64(n0)
+			}
+		}
+		if bestErr < 0 || e < bestErr-1e-9 {
+			best, bestErr, bestSum = mask, e, sum
+		}
+	}
+	n1 := bits.OnesCount8(best)
+	return best, valid, mean(bestSum, n1), mean([3]int{total[0] - bestSum[0], total[1] - bestSum[1], total[2] - bestSum[2]}, count-n1)
+}
+
+// matches returns the number of the previous foreground and background colors
+// matching f and g.
+func matches(fg, bg color.Color, f, g color.NRGBA) int {
+	n := 0
+	if fg == color.Color(f) {
+		n++
+	}
+	if bg == color.Color(g) {
+		n++
+	}
+	return n
+}
+
+// mean returns the mean color of the sum of n colors.
+func mean(sum [3]int, n int) color.NRGBA {
+	if n == 0 {
+		return color.NRGBA{A: 0xff}
+	}
+	return color.NRGBA{uint8((sum[0] + n/2) / n), uint8((sum[1] + n/2) / n), uint8((sum[2] + n/2) / n), 0xff}
+}
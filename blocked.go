@@ -11,11 +11,15 @@ import (
 	"io"
 	"maps"
 	"math/bits"
+	"slices"
 	"strings"
 	"sync"
 	"unicode/utf8"
 )
 
+// ErrUnknownType is the unknown block type error.
+var ErrUnknownType = errors.New("unknown block type")
+
 var (
 	// DefaultScaleWidth is the pixel width scale for bitmaps used as
 	// [image.Image].
@@ -27,13 +31,146 @@ var (
 	DefaultOpaque = color.Opaque
 	// DefaultTransparent is the default transparent color.
 	DefaultTransparent = color.Transparent
+	// DefaultCellAspect is the default terminal cell aspect ratio (height /
+	// width).
+	DefaultCellAspect = 2.0
+)
+
+// Option is a bitmap option.
+type Option func(*options)
+
+// WithThreshold is a bitmap option to set the [Thresholder] used when
+// converting images.
+func WithThreshold(threshold Thresholder) Option {
+	return func(o *options) {
+		o.threshold = threshold
+	}
+}
+
+// WithDitherer is a bitmap option to set the [Ditherer] used when converting
+// images. When set, the threshold level is ignored.
+func WithDitherer(ditherer Ditherer) Option {
+	return func(o *options) {
+		o.ditherer = ditherer
+	}
+}
+
+// WithAlphaThreshold is a bitmap option to set the alpha level below which
+// pixels are unset when converting images.
+func WithAlphaThreshold(level uint8) Option {
+	return func(o *options) {
+		o.alpha = level
+	}
+}
+
+// WithInvert is a bitmap option to invert thresholded pixels when converting
+// images, setting the bits for pixels below the threshold level.
+func WithInvert() Option {
+	return func(o *options) {
+		o.invert = true
+	}
+}
+
+// WithBitOrder is a bitmap option to set the [BitOrder] of the bits within
+// each byte of data when creating bitmaps.
+func WithBitOrder(order BitOrder) Option {
+	return func(o *options) {
+		o.order = order
+	}
+}
+
+// WithAlign is a bitmap option to set the bit alignment of each row of data
+// when creating bitmaps, such as 8 for rows padded to a byte, or 32 for rows
+// padded to a 32-bit word. See [AlignStride].
+func WithAlign(align int) Option {
+	return func(o *options) {
+		o.align = align
+	}
+}
+
+// WithByteOrder is a bitmap option to set the byte order used by [New] when
+// writing multi-byte data. Defaults to [binary.NativeEndian].
+func WithByteOrder(byteOrder binary.ByteOrder) Option {
+	return func(o *options) {
+		o.byteOrder = byteOrder
+	}
+}
+
+// WithWordOrder is a bitmap option to set the bit order of each word (each
+// integer) of the data used by [New], where [MSBFirst] is the most significant
+// bit of each word being the leftmost pixel, as is common with glyph rows
+// written as binary literals (such as 0b1000000000000001). Sets both the byte
+// order and the [BitOrder] of the bitmap, overriding prior [WithByteOrder] and
+// [WithBitOrder] options.
+func WithWordOrder(order BitOrder) Option {
+	return func(o *options) {
+		o.order, o.byteOrder = order, binary.LittleEndian
+		if order == MSBFirst {
+			o.byteOrder = binary.BigEndian
+		}
+	}
+}
+
+// options are bitmap options.
+type options struct {
+	threshold Thresholder
+	ditherer  Ditherer
+	alpha     uint8
+	invert    bool
+	order     BitOrder
+	align     int
+	byteOrder binary.ByteOrder
+}
+
+// newOptions creates bitmap options.
+func newOptions(opts ...Option) options {
+	o := options{
+		threshold: Level(128),
+		alpha:     128,
+		byteOrder: binary.NativeEndian,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// BitOrder is the order of the bits within each byte of a bitmap.
+type BitOrder int
+
+// Bit orders.
+const (
+	// LSBFirst is least significant bit first order, where the first pixel
+	// is bit 0 (mask 0x01) of each byte.
+	LSBFirst BitOrder = iota
+	// MSBFirst is most significant bit first order, where the first pixel
+	// is bit 7 (mask 0x80) of each byte, as used by PBM, and 1-bit PNG rows.
+	MSBFirst
 )
 
+// String satisfies the [fmt.Stringer] interface.
+func (order BitOrder) String() string {
+	switch order {
+	case LSBFirst:
+		return "LSBFirst"
+	case MSBFirst:
+		return "MSBFirst"
+	}
+	return ""
+}
+
 // Bitmap is a monotone bitmap image.
+//
+// Pix holds the bits of the bitmap, with Stride being the bit distance
+// between vertically adjacent pixels, and Offset being the bit offset of
+// Rect.Min in Pix (non-zero for bitmaps returned by [Bitmap.SubImage]). Order
+// is the order of the bits within each byte of Pix.
 type Bitmap struct {
 	Pix         []uint8
 	Stride      int
 	Rect        image.Rectangle
+	Offset      int
+	Order       BitOrder
 	ScaleWidth  uint
 	ScaleHeight uint
 	Opaque      color.Alpha16
@@ -41,17 +178,18 @@ type Bitmap struct {
 }
 
 // New creates a new bitmap from data with width x. Data can be any type that
-// works with [binary.Write].
-func New(data any, x int) (Bitmap, error) {
+// works with [binary.Write]. Use [WithByteOrder] or [WithWordOrder] to
+// control how multi-byte data is converted.
+func New(data any, x int, opts ...Option) (Bitmap, error) {
 	var buf bytes.Buffer
-	if err := binary.Write(&buf, binary.NativeEndian, data); err != nil {
+	if err := binary.Write(&buf, newOptions(opts...).byteOrder, data); err != nil {
 		return Bitmap{}, err
 	}
-	return NewReader(&buf, x)
+	return NewReader(&buf, x, opts...)
 }
 
 // NewReader creates a new bitmap from the reader with bit width x.
-func NewReader(r io.Reader, x int) (Bitmap, error) {
+func NewReader(r io.Reader, x int, opts ...Option) (Bitmap, error) {
 	data, buf := make([]byte, 0, 512), make([]byte, 512)
 	var err error
 	var c int
@@ -65,27 +203,70 @@ loop: // read
 		}
 		data = append(data, buf[:c]...)
 	}
-	return NewBytes(data, x, (len(data)*8+7)/x)
+	stride := AlignStride(x, newOptions(opts...).align)
+	return NewBytes(data, x, (len(data)*8+7)/stride, opts...)
 }
 
 // NewBytes creates a new bitmap from for the unaligned bytes in data with
-// width x, height y.
-func NewBytes(data []byte, x, y int) (Bitmap, error) {
-	pix := make([]byte, (x*y+7)/8)
+// width x, height y. Use [WithBitOrder] when the bits in data are not
+// [LSBFirst], and [WithAlign] when the rows in data are padded.
+func NewBytes(data []byte, x, y int, opts ...Option) (Bitmap, error) {
+	o := newOptions(opts...)
+	stride := AlignStride(x, o.align)
+	pix := make([]byte, (stride*y+7)/8)
 	copy(pix, data)
-	if m := x * y % 8; m != 0 {
-		// clear upper bits of last byte
-		pix[len(pix)-1] &= 0xff >> (8 - m)
+	if m := stride * y % 8; m != 0 {
+		// clear unused bits of last byte
+		if o.order == MSBFirst {
+			pix[len(pix)-1] &= 0xff << (8 - m)
+		} else {
+			pix[len(pix)-1] &= 0xff >> (8 - m)
+		}
 	}
 	return Bitmap{
 		Pix:         pix,
-		Stride:      x,
+		Stride:      stride,
 		Rect:        image.Rect(0, 0, x, y),
+		Order:       o.order,
 		Opaque:      DefaultOpaque,
 		Transparent: DefaultTransparent,
 	}, nil
 }
 
+// Wrap creates a new bitmap with width x, height y, wrapping the bits in data
+// without copying. Stride is the bit distance between the start of adjacent
+// rows in data, and must be at least x. See [AlignStride]. Use [WithBitOrder]
+// when the bits in data are not [LSBFirst].
+//
+// Returns [io.ErrShortBuffer] when data is too short.
+func Wrap(data []byte, x, y, stride int, opts ...Option) (Bitmap, error) {
+	switch {
+	case x < 0 || y < 0:
+		return Bitmap{}, fmt.Errorf("invalid size %dx%d", x, y)
+	case stride < x:
+		return Bitmap{}, fmt.Errorf("invalid stride %d", stride)
+	case y != 0 && len(data) < ((y-1)*stride+x+7)/8:
+		return Bitmap{}, io.ErrShortBuffer
+	}
+	return Bitmap{
+		Pix:         data,
+		Stride:      stride,
+		Rect:        image.Rect(0, 0, x, y),
+		Order:       newOptions(opts...).order,
+		Opaque:      DefaultOpaque,
+		Transparent: DefaultTransparent,
+	}, nil
+}
+
+// AlignStride returns the bit stride for rows of width x padded to a
+// multiple of align bits.
+func AlignStride(x, align int) int {
+	if align <= 1 {
+		return x
+	}
+	return (x + align - 1) / align * align
+}
+
 // NewImage creates a blank bitmap image with dimensions in rect.
 func NewImage(rect image.Rectangle) Bitmap {
 	x := rect.Dx()
@@ -98,19 +279,74 @@ func NewImage(rect image.Rectangle) Bitmap {
 	}
 }
 
-// Set sets the bit at x, y.
+// PixOffset returns the offset of the bit in Pix for x, y.
+func (img Bitmap) PixOffset(x, y int) int {
+	return img.Offset + (y-img.Rect.Min.Y)*img.Stride + (x - img.Rect.Min.X)
+}
+
+// Set sets the bit at x, y. Does nothing when x, y is not in the bitmap's
+// bounds.
 func (img Bitmap) Set(x, y int, b bool) {
-	if i := y*img.Stride + x; b {
-		img.Pix[i/8] |= 1 << (i % 8)
+	if !image.Pt(x, y).In(img.Rect) {
+		return
+	}
+	if i := img.PixOffset(x, y); b {
+		img.Pix[i/8] |= img.mask(i)
 	} else {
-		img.Pix[i/8] &= ^(1 << (i % 8))
+		img.Pix[i/8] &^= img.mask(i)
 	}
 }
 
-// Get returns the bit at x, y.
+// Get returns the bit at x, y. Returns false when x, y is not in the
+// bitmap's bounds.
 func (img Bitmap) Get(x, y int) bool {
-	i := y*img.Stride + x
-	return img.Pix[i/8]&(1<<(i%8)) != 0
+	if !image.Pt(x, y).In(img.Rect) {
+		return false
+	}
+	i := img.PixOffset(x, y)
+	return img.Pix[i/8]&img.mask(i) != 0
+}
+
+// mask returns the mask of the bit at offset i in its byte of Pix.
+func (img Bitmap) mask(i int) uint8 {
+	if img.Order == MSBFirst {
+		return 0x80 >> (i % 8)
+	}
+	return 1 << (i % 8)
+}
+
+// SubImage returns a bitmap representing the portion of the bitmap visible
+// through r. The returned bitmap shares pixels with the original bitmap.
+func (img Bitmap) SubImage(r image.Rectangle) Bitmap {
+	r = r.Intersect(img.Rect)
+	sub := img
+	// if r is empty, the intersection is not guaranteed to be inside the
+	// bitmap's bounds
+	if r.Empty() {
+		sub.Pix, sub.Rect, sub.Offset = nil, image.Rectangle{}, 0
+		return sub
+	}
+	i := img.PixOffset(r.Min.X, r.Min.Y)
+	sub.Pix, sub.Rect, sub.Offset = img.Pix[i/8:], r, i%8
+	return sub
+}
+
+// Clone returns a copy of the bitmap, with Stride being the width of the
+// bitmap, and the same bit order.
+func (img Bitmap) Clone() Bitmap {
+	dst := img.blank(img.Rect)
+	Blit(dst, img, img.Rect.Min, OpCopy)
+	return dst
+}
+
+// blank returns a blank bitmap with bounds r and the same bit order, scale and
+// colors as the bitmap.
+func (img Bitmap) blank(r image.Rectangle) Bitmap {
+	dst := NewImage(r)
+	dst.Order = img.Order
+	dst.ScaleWidth, dst.ScaleHeight = img.ScaleWidth, img.ScaleHeight
+	dst.Opaque, dst.Transparent = img.Opaque, img.Transparent
+	return dst
 }
 
 // ColorModel satisfies the [image.Image] interface.
@@ -118,15 +354,16 @@ func (img Bitmap) ColorModel() color.Model {
 	return color.Alpha16Model
 }
 
-// Bounds satisfies the [image.Image] interface.
+// Bounds satisfies the [image.Image] interface. The bounds are the bitmap's
+// bounds scaled by [Bitmap.Scale].
 func (img Bitmap) Bounds() image.Rectangle {
 	w, h := img.Scale()
-	return image.Rect(0, 0, w*img.Rect.Dx(), h*img.Rect.Dy())
+	return image.Rect(w*img.Rect.Min.X, h*img.Rect.Min.Y, w*img.Rect.Max.X, h*img.Rect.Max.Y)
 }
 
 // At satisfies the [image.Image] interface.
 func (img Bitmap) At(x, y int) color.Color {
-	if w, h := img.Scale(); img.Get(x/w, y/h) {
+	if w, h := img.Scale(); img.Get(floorDiv(x, w), floorDiv(y, h)) {
 		return img.Opaque
 	}
 	return img.Transparent
@@ -136,9 +373,9 @@ func (img Bitmap) At(x, y int) color.Color {
 func (img Bitmap) Width(typ Type) int {
 	w := typ.Width()
 	if w == 0 {
-		return img.Stride * 2
+		return img.Rect.Dx() * 2
 	}
-	return (img.Stride + w - 1) / w
+	return (img.Rect.Dx() + w - 1) / w
 }
 
 // Height returns the height for the block type.
@@ -153,8 +390,8 @@ func (img Bitmap) Format(f fmt.State, verb rune) {
 	case Auto, 's':
 		typ = img.Best()
 		fallthrough
-	case Solids, Binaries, XXs,
-		Doubles,
+	case Solids, Binaries, XXs, Shades,
+		Doubles, Emojis,
 		Halves, ASCIIs,
 		Quads, QuadsSeparated,
 		Sextants, SextantsSeparated,
@@ -163,6 +400,12 @@ func (img Bitmap) Format(f fmt.State, verb rune) {
 			fmt.Fprintf(f, "%%!%c(ERROR: %v)", verb, err)
 		}
 	default:
+		if _, ok := lookup(typ); ok {
+			if err := img.Encode(f, typ); err != nil {
+				fmt.Fprintf(f, "%%!%c(ERROR: %v)", verb, err)
+			}
+			return
+		}
 		fmt.Fprintf(f, "%%!%c(BAD VERB)", verb)
 	}
 }
@@ -176,32 +419,23 @@ func (img Bitmap) Bytes() []byte {
 	return buf.Bytes()
 }
 
-// Encode encodes the bitmap to the writer using the block type.
+// Encode encodes the bitmap to the writer using the block type. Returns
+// [ErrUnknownType] when the block type is not built-in or registered.
 func (img Bitmap) Encode(w io.Writer, typ Type) error {
 	if typ == Auto {
 		typ = img.Best()
 	}
-	var f func(io.Writer, []byte, int, int, map[uint8]rune) error
-	switch w, h := typ.Width(), typ.Height(); {
-	case w == 0:
-		f = enc0_5x1
-	case w == 1 && h == 1:
-		f = enc1x1
-	case w == 1 && h == 2:
-		f = enc1x2
-	case h == 2:
-		f = enc2x2
-	case h == 3:
-		f = enc2x3
-	case h == 4:
-		f = enc2x4
-	}
-	return f(w, img.Pix, img.Stride, img.Rect.Dy(), typ.runeMap())
-}
-
-// Best returns the [Best] block type for the image.
+	syms, x, y, n := typ.encoding()
+	if syms == nil {
+		return ErrUnknownType
+	}
+	return enc(w, img, x, y, n, syms)
+}
+
+// Best returns the best block type for the image using the
+// [DefaultCapabilities]. See [Capabilities.Best].
 func (img Bitmap) Best() Type {
-	return Best(img.Rect.Dy())
+	return DefaultCapabilities.Best(img.Rect.Dx(), img.Rect.Dy())
 }
 
 // Scale returns the image width and height scale factors.
@@ -216,175 +450,188 @@ func (img Bitmap) Scale() (int, int) {
 	return int(w), int(h)
 }
 
-// enc0_5x1 encodes 0.5x1 blocks to the writer, used when width == 0 for the
-// [Type].
-func enc0_5x1(wr io.Writer, buf []byte, w, h int, syms map[uint8]rune) (err error) {
-	i, m, b, v, o := 0, 0, uint8(0), make([]byte, 8), 0
-	for y := range h {
-		for x := range w {
-			i = y*w + x
-			m = i % 8
-			b = buf[i/8] & (1 << m) >> m
-			o = utf8.EncodeRune(v, syms[b])
-			copy(v[o:], v[:o])
-			if _, err = wr.Write(v[:2*o]); err != nil {
-				return err
-			}
+// enc encodes w x h blocks of the bitmap to the writer, writing each block's
+// rune n times.
+//
+// Bits of bitmaps with a zero offset and [LSBFirst] order are read directly
+// from Pix, otherwise bits are read using [Bitmap.Get].
+func enc(wr io.Writer, img Bitmap, w, h, n int, syms map[uint8]rune) error {
+	r, v := img.Rect, make([]byte, 4)
+	direct := img.Offset == 0 && img.Order == LSBFirst
+	var runes [256]rune
+	for b, c := range syms {
+		runes[b] = c
+	}
+	var buf []byte
+	for y := r.Min.Y; y < r.Max.Y; y += h {
+		buf = buf[:0]
+		if y != r.Min.Y {
+			buf = append(buf, nl...)
 		}
-		if y < h-1 {
-			if _, err = wr.Write(nl); err != nil {
-				return err
+		for x := r.Min.X; x < r.Max.X; x += w {
+			var b uint8
+			if direct {
+				bw, bh := min(w, r.Max.X-x), min(h, r.Max.Y-y)
+				for j := range bh {
+					k := (y-r.Min.Y+j)*img.Stride + x - r.Min.X
+					for i := range bw {
+						b |= img.Pix[(k+i)/8] >> ((k + i) % 8) & 1 << (j*w + i)
+					}
+				}
+			} else {
+				for i := range w * h {
+					if img.Get(x+i%w, y+i/w) {
+						b |= 1 << i
+					}
+				}
 			}
-		}
-	}
-	return nil
-}
-
-// enc1x1 encodes 1x1 blocks to the writer.
-func enc1x1(wr io.Writer, buf []byte, w, h int, syms map[uint8]rune) (err error) {
-	i, m, b, v := 0, 0, uint8(0), make([]byte, 4)
-	for y := range h {
-		for x := range w {
-			i = y*w + x
-			m = i % 8
-			b = buf[i/8] & (1 << m) >> m
-			if _, err = wr.Write(v[:utf8.EncodeRune(v, syms[b])]); err != nil {
-				return err
+			c := v[:utf8.EncodeRune(v, runes[b])]
+			for range n {
+				buf = append(buf, c...)
 			}
 		}
-		if y < h-1 {
-			if _, err = wr.Write(nl); err != nil {
-				return err
-			}
+		if _, err := wr.Write(buf); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-// enc1x2 encodes 1x2 blocks to the writer.
-func enc1x2(wr io.Writer, buf []byte, w, h int, syms map[uint8]rune) (err error) {
-	buf = append(buf, make([]byte, (w+7)/8*(h+1)/2)...)
-	i, d, m, b, v := 0, 0, 0, uint8(0), make([]byte, 4)
-	for y := 0; y < h; y += 2 {
-		for x := range w {
-			i = y*w + x
-			d, m = i/8, i%8
-			b = buf[d] & (1 << m) >> m
-			d, m = (i+w)/8, (i+w)%8
-			b |= buf[d] & (1 << m) >> m << 1
-			if _, err = wr.Write(v[:utf8.EncodeRune(v, syms[b])]); err != nil {
-				return err
+// Decode decodes a bitmap from the blocks of the block type read from the
+// reader, reversing [Bitmap.Encode].
+//
+// As the original dimensions cannot be recovered from the blocks, the decoded
+// bitmap's width and height will be a multiple of the block type's width and
+// height. Lines shorter than the longest line are padded with unset bits, and
+// a carriage return preceding a newline is ignored. Returns a [*DecodeError]
+// when a rune is not valid for the block type, and [ErrUnknownType] when the
+// block type is not known.
+func Decode(r io.Reader, typ Type) (Bitmap, error) {
+	syms := typ.indexMap()
+	if syms == nil {
+		return Bitmap{}, ErrUnknownType
+	}
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return Bitmap{}, err
+	}
+	w, h, n := typ.Width(), typ.Height(), 1
+	if w == 0 {
+		w, n = 1, typ.repeat()
+	}
+	// decode lines
+	lines := bytes.Split(buf, nl)
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	cells, width := make([][]uint8, len(lines)), 0
+	for i, line := range lines {
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+		for col := 1; len(line) != 0; col++ {
+			c, sz := utf8.DecodeRune(line)
+			b, ok := syms[c]
+			if !ok {
+				return Bitmap{}, &DecodeError{Line: i + 1, Column: col, Rune: c}
 			}
+			if typ == Shades {
+				// set for the medium shade and darker
+				b = min(b/2, 1)
+			}
+			line = line[sz:]
+			// doubled blocks repeat the rune
+			for j := 1; j < n && len(line) != 0; j++ {
+				col++
+				d, sz := utf8.DecodeRune(line)
+				if d != c {
+					return Bitmap{}, &DecodeError{Line: i + 1, Column: col, Rune: d}
+				}
+				line = line[sz:]
+			}
+			cells[i] = append(cells[i], b)
 		}
-		if y < h-2 {
-			if _, err = wr.Write(nl); err != nil {
-				return err
+		width = max(width, len(cells[i]))
+	}
+	// set bits
+	img := NewImage(image.Rect(0, 0, width*w, len(cells)*h))
+	for y, row := range cells {
+		for x, b := range row {
+			for i := range w * h {
+				if b&(1<<i) != 0 {
+					img.Set(x*w+i%w, y*h+i/w, true)
+				}
 			}
 		}
 	}
-	return nil
+	return img, nil
 }
 
-// enc2x2 encodes 2x2 blocks to the writer.
-func enc2x2(wr io.Writer, buf []byte, w, h int, syms map[uint8]rune) (err error) {
-	buf = append(buf, make([]byte, (w+7)/8*(h+3)/2)...)
-	i, d, m, b, v := 0, 0, 0, uint8(0), make([]byte, 4)
-	for y := 0; y < h; y += 2 {
-		for x := 0; x < w; x += 2 {
-			i = y*w + x
-			d, m = i/8, i%8
-			b = buf[d] & (1 << m) >> m
-			d, m = (i+w)/8, (i+w)%8
-			b |= buf[d] & (1 << m) >> m << 2
-			if x+2 <= w {
-				d, m = (i+1)/8, (i+1)%8
-				b |= buf[d] & (1 << m) >> m << 1
-				d, m = (i+w+1)/8, (i+w+1)%8
-				b |= buf[d] & (1 << m) >> m << 3
-			}
-			if _, err = wr.Write(v[:utf8.EncodeRune(v, syms[b])]); err != nil {
-				return err
-			}
-		}
-		if y < h-2 {
-			if _, err = wr.Write(nl); err != nil {
-				return err
-			}
-		}
+// DecodeAuto decodes a bitmap from the blocks read from the reader, using
+// [Sniff] to determine the block type. Returns [ErrUnknownType] when the block
+// type cannot be determined.
+func DecodeAuto(r io.Reader) (Bitmap, Type, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return Bitmap{}, 0, err
 	}
-	return nil
+	typ, ok := Sniff(buf)
+	if !ok {
+		return Bitmap{}, 0, ErrUnknownType
+	}
+	img, err := Decode(bytes.NewReader(buf), typ)
+	if err != nil {
+		return Bitmap{}, 0, err
+	}
+	return img, typ, nil
 }
 
-// enc2x3 encodes 2x3 blocks to the writer.
-func enc2x3(wr io.Writer, buf []byte, w, h int, syms map[uint8]rune) (err error) {
-	buf = append(buf, make([]byte, (w+7)/8*(h+5)/3)...)
-	i, d, m, b, v := 0, 0, 0, uint8(0), make([]byte, 4)
-	for y := 0; y < h; y += 3 {
-		for x := 0; x < w; x += 2 {
-			i = y*w + x
-			d, m = i/8, i%8
-			b = buf[d] & (1 << m) >> m
-			d, m = (i+w)/8, (i+w)%8
-			b |= buf[d] & (1 << m) >> m << 2
-			d, m = (i+2*w)/8, (i+2*w)%8
-			b |= buf[d] & (1 << m) >> m << 4
-			if x+2 <= w {
-				d, m = (i+1)/8, (i+1)%8
-				b |= buf[d] & (1 << m) >> m << 1
-				d, m = (i+w+1)/8, (i+w+1)%8
-				b |= buf[d] & (1 << m) >> m << 3
-				d, m = (i+2*w+1)/8, (i+2*w+1)%8
-				b |= buf[d] & (1 << m) >> m << 5
-			}
-			if _, err = wr.Write(v[:utf8.EncodeRune(v, syms[b])]); err != nil {
-				return err
-			}
+// Sniff returns the most specific block type having all runes in buf, other
+// than newlines and carriage returns. Returns false when there is no such
+// block type.
+//
+// The most specific block type is the one with the lowest [Type.RuneCount],
+// with ties resolved by the order of [Types]. As such, blocks consisting only
+// of ' ' and '█' (or empty blocks) will always be [Solids], and never
+// [Doubles].
+func Sniff(buf []byte) (Type, bool) {
+	runes := make(map[rune]bool)
+	for _, r := range string(buf) {
+		if r != '\n' && r != '\r' {
+			runes[r] = true
 		}
-		if y < h-3 {
-			if _, err = wr.Write(nl); err != nil {
-				return err
-			}
+	}
+	var typ Type
+	n := -1
+	for _, t := range Types() {
+		if c := t.RuneCount(); n != -1 && n <= c {
+			continue
+		}
+		if covers(t.indexMap(), runes) {
+			typ, n = t, t.RuneCount()
 		}
 	}
-	return nil
+	return typ, n != -1
 }
 
-// enc2x4 encodes 2x4 blocks to the writer.
-func enc2x4(wr io.Writer, buf []byte, w, h int, syms map[uint8]rune) (err error) {
-	buf = append(buf, make([]byte, (w+7)/8*(h+11)/4)...)
-	i, d, m, b, v := 0, 0, 0, uint8(0), make([]byte, 4)
-	for y := 0; y < h; y += 4 {
-		for x := 0; x < w; x += 2 {
-			i = y*w + x
-			d, m = i/8, i%8
-			b = buf[d] & (1 << m) >> m
-			d, m = (i+w)/8, (i+w)%8
-			b |= buf[d] & (1 << m) >> m << 2
-			d, m = (i+2*w)/8, (i+2*w)%8
-			b |= buf[d] & (1 << m) >> m << 4
-			d, m = (i+3*w)/8, (i+3*w)%8
-			b |= buf[d] & (1 << m) >> m << 6
-			if x+2 <= w {
-				d, m = (i+1)/8, (i+1)%8
-				b |= buf[d] & (1 << m) >> m << 1
-				d, m = (i+w+1)/8, (i+w+1)%8
-				b |= buf[d] & (1 << m) >> m << 3
-				d, m = (i+2*w+1)/8, (i+2*w+1)%8
-				b |= buf[d] & (1 << m) >> m << 5
-				d, m = (i+3*w+1)/8, (i+3*w+1)%8
-				b |= buf[d] & (1 << m) >> m << 7
-			}
-			if _, err = wr.Write(v[:utf8.EncodeRune(v, syms[b])]); err != nil {
-				return err
-			}
-		}
-		if y < h-4 {
-			if _, err = wr.Write(nl); err != nil {
-				return err
-			}
+// covers returns true when all runes are in syms.
+func covers(syms map[rune]uint8, runes map[rune]bool) bool {
+	for r := range runes {
+		if _, ok := syms[r]; !ok {
+			return false
 		}
 	}
-	return nil
+	return true
+}
+
+// DecodeError is a decode error.
+type DecodeError struct {
+	Line   int
+	Column int
+	Rune   rune
+}
+
+// Error satisfies the [error] interface.
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("invalid rune %q at line %d, column %d", err.Rune, err.Line, err.Column)
 }
 
 // Type is a block type.
@@ -392,8 +639,8 @@ type Type rune
 
 // Block types.
 const (
-	// Auto uses [Best] to determine the best, contiguous block type to use for
-	// a provided height.
+	// Auto uses [Bitmap.Best] to determine the best, supported block type to
+	// use for a bitmap.
 	Auto Type = 'v'
 	// Solids are single, 1x1 blocks using [SolidsRunes].
 	Solids Type = 'l'
@@ -401,8 +648,14 @@ const (
 	Binaries Type = 'b'
 	// XXs are single, 1x1 blocks using [XXsRunes].
 	XXs Type = 'L'
+	// Shades are single, 1x1 blocks using the shades of [ShadesRunes]. When
+	// encoding a bitmap, only the lightest and darkest shades are used. See
+	// [EncodeShades] for encoding the intensity of grayscale images.
+	Shades Type = 'g'
 	// Doubles are single, 0.5x1 double wide blocks using [SolidsRunes].
 	Doubles Type = 'D'
+	// Emojis are single, 0.5x1 double wide emoji squares using [EmojisRunes].
+	Emojis Type = 'm'
 	// Halves are 0.5x1 double wide blocks using [HalvesRunes].
 	Halves Type = 'e'
 	// Halves are 1x2 double height blocks using ASCII-safe runes using
@@ -424,11 +677,13 @@ const (
 
 // Types returns all block types.
 func Types() []Type {
-	return []Type{
+	types := []Type{
 		Solids,
 		Binaries,
 		XXs,
+		Shades,
 		Doubles,
+		Emojis,
 		Halves,
 		ASCIIs,
 		Quads,
@@ -438,6 +693,7 @@ func Types() []Type {
 		Octants,
 		Braille,
 	}
+	return append(types, customTypes()...)
 }
 
 // String satisfies the [fmt.Stringer] interface.
@@ -449,8 +705,12 @@ func (typ Type) String() string {
 		return "Binaries"
 	case XXs:
 		return "XXs"
+	case Shades:
+		return "Shades"
 	case Doubles:
 		return "Doubles"
+	case Emojis:
+		return "Emojis"
 	case Halves:
 		return "Halves"
 	case ASCIIs:
@@ -468,6 +728,9 @@ func (typ Type) String() string {
 	case Braille:
 		return "Braille"
 	}
+	if c, ok := lookup(typ); ok {
+		return c.name
+	}
 	return ""
 }
 
@@ -488,8 +751,10 @@ func (typ Type) Rune() rune {
 // RuneCount returns the number of runes for the block type.
 func (typ Type) RuneCount() int {
 	switch typ {
-	case Solids, Binaries, XXs, Doubles:
+	case Solids, Binaries, XXs, Doubles, Emojis:
 		return 2
+	case Shades:
+		return 5
 	case Halves, ASCIIs:
 		return 4
 	case Quads, QuadsSeparated:
@@ -499,26 +764,32 @@ func (typ Type) RuneCount() int {
 	case Octants, Braille:
 		return 256
 	}
+	if c, ok := lookup(typ); ok {
+		return len(c.runes)
+	}
 	return -1
 }
 
 // Width returns the width for the block type.
 func (typ Type) Width() int {
 	switch typ {
-	case Doubles:
+	case Doubles, Emojis:
 		return 0
-	case Solids, Binaries, XXs, Halves, ASCIIs:
+	case Solids, Binaries, XXs, Shades, Halves, ASCIIs:
 		return 1
 	case Quads, QuadsSeparated, Sextants, SextantsSeparated, Octants, Braille:
 		return 2
 	}
+	if c, ok := lookup(typ); ok {
+		return c.w
+	}
 	return -1
 }
 
 // Height returns the height for the block type.
 func (typ Type) Height() int {
 	switch typ {
-	case Solids, Binaries, XXs, Doubles:
+	case Solids, Binaries, XXs, Shades, Doubles, Emojis:
 		return 1
 	case Halves, ASCIIs, Quads, QuadsSeparated:
 		return 2
@@ -527,9 +798,32 @@ func (typ Type) Height() int {
 	case Octants, Braille:
 		return 4
 	}
+	if c, ok := lookup(typ); ok {
+		return c.h
+	}
 	return -1
 }
 
+// Aspect returns the displayed pixel aspect ratio (height / width) for the
+// block type when rendered in terminal cells with the cell aspect ratio. A
+// value of 1 means pixels are displayed square.
+//
+// When cell is 0, [DefaultCellAspect] is used.
+func (typ Type) Aspect(cell float64) float64 {
+	if cell <= 0 {
+		cell = DefaultCellAspect
+	}
+	w, h := float64(typ.Width()), float64(typ.Height())
+	switch {
+	case w == 0:
+		// double wide
+		w = 0.5
+	case w < 0 || h <= 0:
+		return 0
+	}
+	return cell * w / h
+}
+
 // ToRune converts a byte to its block rune.
 func (typ Type) ToRune(b uint8) rune {
 	if m := typ.runeMap(); m != nil {
@@ -552,8 +846,12 @@ func (typ Type) Runes() []rune {
 		return BinariesRunes()
 	case XXs:
 		return XXsRunes()
+	case Shades:
+		return ShadesRunes()
 	case Doubles:
 		return SolidsRunes()
+	case Emojis:
+		return EmojisRunes()
 	case Halves:
 		return HalvesRunes()
 	case ASCIIs:
@@ -571,6 +869,9 @@ func (typ Type) Runes() []rune {
 	case Braille:
 		return BrailleRunes()
 	}
+	if c, ok := lookup(typ); ok {
+		return slices.Clone(c.runes)
+	}
 	return nil
 }
 
@@ -582,29 +883,93 @@ func (typ Type) Dump(w io.Writer) {
 	}
 }
 
-// runeMap returns the rune map for the type.
-func (typ Type) runeMap() map[uint8]rune {
+// encoding returns the rune map, block width and height, and the number of
+// times to write each rune when encoding the block type.
+func (typ Type) encoding() (map[uint8]rune, int, int, int) {
+	syms := typ.runeMap()
+	if typ == Shades {
+		// lightest and darkest shades
+		syms = map[uint8]rune{0: syms[0], 1: syms[uint8(len(syms)-1)]}
+	}
+	x, y, n := typ.Width(), typ.Height(), 1
+	if x == 0 {
+		// double wide
+		x, n = 1, typ.repeat()
+	}
+	return syms, x, y, n
+}
+
+// repeat returns the number of times a double wide block type's runes are
+// repeated.
+func (typ Type) repeat() int {
+	if typ == Doubles {
+		return 2
+	}
+	// emojis are double wide
+	return 1
+}
+
+// builtin returns true when the type is a built-in block type.
+func (typ Type) builtin() bool {
 	switch typ {
-	case Solids, Binaries, XXs,
-		Doubles,
+	case Solids, Binaries, XXs, Shades,
+		Doubles, Emojis,
 		Halves, ASCIIs,
 		Quads, QuadsSeparated,
 		Sextants, SextantsSeparated,
 		Octants, Braille:
-		blocksMu.Lock()
-		defer blocksMu.Unlock()
-		b, ok := blocks[typ]
-		if !ok {
-			v := typ.Runes()
-			b = make(map[uint8]rune, len(v))
-			for i, r := range v {
-				b[uint8(i)] = r
-			}
-			blocks[typ] = b
+		return true
+	}
+	return false
+}
+
+// runeMap returns the rune map for the type.
+func (typ Type) runeMap() map[uint8]rune {
+	if !typ.builtin() {
+		if _, ok := lookup(typ); !ok {
+			return nil
 		}
-		return b
 	}
-	return nil
+	blocksMu.Lock()
+	defer blocksMu.Unlock()
+	b, ok := blocks[typ]
+	if !ok {
+		v := typ.Runes()
+		b = make(map[uint8]rune, len(v))
+		for i, r := range v {
+			b[uint8(i)] = r
+		}
+		blocks[typ] = b
+	}
+	return b
+}
+
+// indexMap returns the reverse rune map for the type.
+func (typ Type) indexMap() map[rune]uint8 {
+	m := typ.runeMap()
+	if m == nil {
+		return nil
+	}
+	indexesMu.Lock()
+	defer indexesMu.Unlock()
+	b, ok := indexes[typ]
+	if !ok {
+		b = make(map[rune]uint8, len(m))
+		for i, r := range m {
+			b[r] = i
+		}
+		indexes[typ] = b
+	}
+	return b
+}
+
+// floorDiv returns x / y, rounded towards negative infinity.
+func floorDiv(x, y int) int {
+	q := x / y
+	if x%y != 0 && x < 0 {
+		q--
+	}
+	return q
 }
 
 // Best returns the best display block type for the height.
@@ -677,6 +1042,10 @@ var (
 	blocks = make(map[Type]map[uint8]rune)
 	// blocksMu is the blocks mutex.
 	blocksMu sync.Mutex
+	// indexes are the reverse block maps.
+	indexes = make(map[Type]map[rune]uint8)
+	// indexesMu is the indexes mutex.
+	indexesMu sync.Mutex
 	// nl is the newline.
 	nl = []byte{'\n'}
 )
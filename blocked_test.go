@@ -2,10 +2,16 @@ package blocked
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/png"
 	"io"
+	"math"
+	"math/bits"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -14,6 +20,7 @@ import (
 	"strings"
 	"testing"
 	"unicode"
+	"unicode/utf8"
 )
 
 func TestBlocks(t *testing.T) {
@@ -205,6 +212,1482 @@ func TestNewReader(t *testing.T) {
 	}
 }
 
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	for seed := 1330; seed <= 1343; seed++ {
+		t.Run(strconv.Itoa(seed), func(t *testing.T) {
+			t.Parallel()
+			img := newTestBitmap(seed)
+			for _, typ := range Types() {
+				t.Run(typ.String(), func(t *testing.T) {
+					buf, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("blocks_%4d_%s.txt.golden", seed, typ)))
+					if err != nil {
+						t.Fatalf("expected no error, got: %v", err)
+					}
+					// strip header and footer
+					lines := bytes.Split(bytes.TrimSuffix(buf, []byte{'\n'}), []byte{'\n'})
+					buf = bytes.Join(lines[1:len(lines)-1], []byte{'\n'})
+					dec, err := Decode(bytes.NewReader(buf), typ)
+					if err != nil {
+						t.Fatalf("expected no error, got: %v", err)
+					}
+					if !img.Rect.In(dec.Rect) {
+						t.Fatalf("expected %v to be in %v", img.Rect, dec.Rect)
+					}
+					for y := range dec.Rect.Dy() {
+						for x := range dec.Rect.Dx() {
+							exp := image.Pt(x, y).In(img.Rect) && img.Get(x, y)
+							if b := dec.Get(x, y); b != exp {
+								t.Errorf("(%d,%d) expected %t, got: %t", x, y, exp, b)
+							}
+						}
+					}
+					var out bytes.Buffer
+					if err := dec.Encode(&out, typ); err != nil {
+						t.Fatalf("expected no error, got: %v", err)
+					}
+					if !bytes.Equal(out.Bytes(), buf) {
+						t.Errorf("expected:\n%s\ngot:\n%s", buf, out.Bytes())
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestEncodeUnknownType(t *testing.T) {
+	t.Parallel()
+	img := newTestBitmap(1337)
+	for _, typ := range []Type{0, Type('z'), Type('!')} {
+		var buf bytes.Buffer
+		if err := img.Encode(&buf, typ); !errors.Is(err, ErrUnknownType) {
+			t.Errorf("%q expected %v, got: %v", rune(typ), ErrUnknownType, err)
+		}
+		if err := img.EncodeColor(&buf, typ, ColorTrue, color.White, nil); !errors.Is(err, ErrUnknownType) {
+			t.Errorf("%q expected %v, got: %v", rune(typ), ErrUnknownType, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%q expected no output, got: %q", rune(typ), buf.String())
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	t.Parallel()
+	_, err := Decode(strings.NewReader("🬀🬁\n🬂x🬃"), Sextants)
+	var e *DecodeError
+	switch {
+	case !errors.As(err, &e):
+		t.Fatalf("expected *DecodeError, got: %v", err)
+	case e.Line != 2 || e.Column != 2 || e.Rune != 'x':
+		t.Errorf("expected line 2, column 2, rune 'x', got: %d, %d, %q", e.Line, e.Column, e.Rune)
+	}
+	if _, err := Decode(strings.NewReader("x"), Type('z')); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType, got: %v", err)
+	}
+}
+
+func TestSniff(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		s   string
+		exp Type
+		ok  bool
+	}{
+		{"", Solids, true},
+		{"  \n  ", Solids, true},
+		{"█ █\r\n ██", Solids, true},
+		{"0110\n1001", Binaries, true},
+		{"X  X", XXs, true},
+		{"⬛⬜\n⬜⬛", Emojis, true},
+		{"▀▄ █", Halves, true},
+		{"^v%", ASCIIs, true},
+		{"▘▀▙", Quads, true},
+		{"𜰡𜰢", QuadsSeparated, true},
+		{"🬀▌🬁", Sextants, true},
+		{"⠁⣿", Braille, true},
+		{"𜴀🮂▘", Octants, true},
+		{"⠁🬀", 0, false},
+		{"abc", 0, false},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+			typ, ok := Sniff([]byte(test.s))
+			if ok != test.ok {
+				t.Fatalf("expected %t, got: %t", test.ok, ok)
+			}
+			if typ != test.exp {
+				t.Errorf("expected %s, got: %s", test.exp, typ)
+			}
+			img, typ, err := DecodeAuto(strings.NewReader(test.s))
+			switch {
+			case !test.ok && !errors.Is(err, ErrUnknownType):
+				t.Fatalf("expected ErrUnknownType, got: %v", err)
+			case test.ok && err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case test.ok && typ != test.exp:
+				t.Errorf("expected %s, got: %s", test.exp, typ)
+			}
+			t.Logf("\n%s", img)
+		})
+	}
+}
+
+func TestFromImage(t *testing.T) {
+	t.Parallel()
+	// horizontal gradient, with a transparent bottom row
+	src := image.NewNRGBA(image.Rect(10, 10, 266, 14))
+	for y := range 4 {
+		for x := range 256 {
+			a := uint8(0xff)
+			if y == 3 {
+				a = 0
+			}
+			src.SetNRGBA(10+x, 10+y, color.NRGBA{uint8(x), uint8(x), uint8(x), a})
+		}
+	}
+	tests := []struct {
+		opts []Option
+		exp  func(x, y int) bool
+	}{
+		{nil, func(x, y int) bool { return y < 3 && 128 <= x }},
+		{[]Option{WithThreshold(Level(200))}, func(x, y int) bool { return y < 3 && 200 <= x }},
+		{[]Option{WithInvert()}, func(x, y int) bool { return y < 3 && x < 128 }},
+		{[]Option{WithThreshold(Level(0))}, func(_, y int) bool { return y < 3 }},
+		{[]Option{WithThreshold(Level(64)), WithAlphaThreshold(0)}, func(x, _ int) bool { return 64 <= x }},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+			img := FromImage(src, test.opts...)
+			if exp := image.Rect(0, 0, 256, 4); img.Rect != exp {
+				t.Fatalf("expected %v, got: %v", exp, img.Rect)
+			}
+			for y := range 4 {
+				for x := range 256 {
+					if exp, b := test.exp(x, y), img.Get(x, y); b != exp {
+						t.Errorf("(%d,%d) expected %t, got: %t", x, y, exp, b)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestOtsu(t *testing.T) {
+	t.Parallel()
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	r := rand.New(rand.NewSource(1337))
+	for i := range img.Pix {
+		if i%2 == 0 {
+			img.Pix[i] = uint8(30 + r.Intn(20))
+		} else {
+			img.Pix[i] = uint8(180 + r.Intn(40))
+		}
+	}
+	if level := Otsu.Threshold(img); level < 50 || 180 < level {
+		t.Errorf("expected level in [50, 180], got: %d", level)
+	}
+}
+
+func TestDitherers(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		d    Ditherer
+		tol  float64
+	}{
+		{"FloydSteinberg", FloydSteinberg, 0.02},
+		// atkinson diffuses only 3/4 of the error
+		{"Atkinson", Atkinson, 0.1},
+		{"JarvisJudiceNinke", JarvisJudiceNinke, 0.02},
+		{"Sierra", Sierra, 0.02},
+		{"Serpentine", ErrorDiffusion{Matrix: FloydSteinberg.Matrix, Offset: 1, Divisor: 16, Serpentine: true}, 0.02},
+		{"Bayer2x2", Bayer2x2, 0.02},
+		{"Bayer4x4", Bayer4x4, 0.02},
+		{"Bayer8x8", Bayer8x8, 0.02},
+		{"BlueNoise", BlueNoise, 0.02},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if d, ok := test.d.(Ordered); ok {
+				v := slices.Clone(d.Map)
+				slices.Sort(v)
+				for i := range v {
+					if v[i] != i {
+						t.Fatalf("expected map to be a permutation of [0, %d), got: %v", len(v), d.Map)
+					}
+				}
+			}
+			for _, level := range []uint8{0, 64, 128, 192, 255} {
+				src := image.NewGray(image.Rect(0, 0, 64, 64))
+				for i := range src.Pix {
+					src.Pix[i] = level
+				}
+				img := FromImage(src, WithDitherer(test.d))
+				n := 0
+				for y := range 64 {
+					for x := range 64 {
+						if img.Get(x, y) {
+							n++
+						}
+					}
+				}
+				exp := float64(level) / 255
+				if v := float64(n) / (64 * 64); v < exp-test.tol || exp+test.tol < v {
+					t.Errorf("level %d expected %f set, got: %f", level, exp, v)
+				}
+			}
+			// gradient
+			src := image.NewGray(image.Rect(0, 0, 128, 32))
+			for y := range 32 {
+				for x := range 128 {
+					src.SetGray(x, y, color.Gray{uint8(2 * x)})
+				}
+			}
+			t.Logf("\n%o", FromImage(src, WithDitherer(test.d)))
+		})
+	}
+}
+
+func TestBlueNoise(t *testing.T) {
+	t.Parallel()
+	exp := voidAndCluster(16, 1.5)
+	if BlueNoise.Size != exp.Size || !slices.Equal(BlueNoise.Map, exp.Map) {
+		t.Errorf("expected BlueNoise to be voidAndCluster(16, 1.5), got:\n%v", exp.Map)
+	}
+}
+
+func TestEncodeColor(t *testing.T) {
+	t.Parallel()
+	img := NewImage(image.Rect(0, 0, 3, 2))
+	img.Set(0, 0, true)
+	img.Set(2, 1, true)
+	red, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+	tests := []struct {
+		mode   ColorMode
+		fg, bg color.Color
+		exp    string
+	}{
+		{ColorNone, red, blue, "█  \n  █"},
+		{Color16, red, nil, "\x1b[91m█  \x1b[0m\n\x1b[91m  █\x1b[0m"},
+		{Color256, red, blue, "\x1b[38;5;196;48;5;21m█  \x1b[0m\n\x1b[38;5;196;48;5;21m  █\x1b[0m"},
+		{ColorTrue, nil, blue, "\x1b[48;2;0;0;255m█  \x1b[0m\n\x1b[48;2;0;0;255m  █\x1b[0m"},
+		{ColorTrue, nil, nil, "█  \n  █"},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := img.EncodeColor(&buf, Solids, test.mode, test.fg, test.bg); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if s := buf.String(); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+}
+
+func TestANSIIndex(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		c      color.NRGBA
+		exp16  int
+		exp256 int
+	}{
+		{color.NRGBA{0, 0, 0, 0xff}, 0, 16},
+		{color.NRGBA{0xff, 0xff, 0xff, 0xff}, 15, 231},
+		{color.NRGBA{0xff, 0, 0, 0xff}, 9, 196},
+		{color.NRGBA{0xcd, 0, 0, 0xff}, 1, 160},
+		{color.NRGBA{0x80, 0x80, 0x80, 0xff}, 8, 244},
+		{color.NRGBA{0x08, 0x08, 0x08, 0xff}, 0, 232},
+	}
+	for i, test := range tests {
+		if n := ansi16Index(test.c); n != test.exp16 {
+			t.Errorf("test %d expected %d, got: %d", i, test.exp16, n)
+		}
+		if n := ansi256Index(test.c); n != test.exp256 {
+			t.Errorf("test %d expected %d, got: %d", i, test.exp256, n)
+		}
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	t.Parallel()
+	red, blue := color.NRGBA{0xff, 0, 0, 0xff}, color.NRGBA{0, 0, 0xff, 0xff}
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			src.SetNRGBA(x, y, blue)
+		}
+	}
+	src.SetNRGBA(0, 0, red)
+	src.SetNRGBA(2, 0, red)
+	src.SetNRGBA(2, 2, red)
+	src.SetNRGBA(3, 3, red)
+	tests := []struct {
+		typ  Type
+		mode ColorMode
+		exp  string
+	}{
+		{Quads, ColorNone, "▘▘\n ▚"},
+		{Quads, ColorTrue, "\x1b[38;2;255;0;0;48;2;0;0;255m▘▘\x1b[0m\n\x1b[48;2;0;0;255m \x1b[38;2;255;0;0m▚\x1b[0m"},
+		{Halves, Color256, "\x1b[38;5;196;48;5;21m▀ ▀ \x1b[0m\n\x1b[48;5;21m  \x1b[38;5;196m▀▄\x1b[0m"},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := EncodeImage(&buf, src, test.typ, test.mode); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if s := buf.String(); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+	if err := EncodeImage(io.Discard, src, Braille, ColorTrue); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestEncodeShades(t *testing.T) {
+	t.Parallel()
+	src := image.NewGray(image.Rect(0, 0, 5, 2))
+	for x, v := range []uint8{0, 64, 128, 192, 255} {
+		src.SetGray(x, 0, color.Gray{v})
+		src.SetGray(4-x, 1, color.Gray{v})
+	}
+	var buf bytes.Buffer
+	if err := EncodeShades(&buf, src); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, exp := buf.String(), " ░▒▓█\n█▓▒░ "; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	img, err := Decode(&buf, Shades)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, exp := fmt.Sprintf("%g", img), "  ███\n███  "; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestGrayBitmap(t *testing.T) {
+	t.Parallel()
+	for _, depth := range []int{2, 4, 8} {
+		t.Run(strconv.Itoa(depth), func(t *testing.T) {
+			t.Parallel()
+			img, err := NewGray(image.Rect(0, 0, 13, 7), depth)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			m := img.Max()
+			if exp := uint8(1<<depth - 1); m != exp {
+				t.Fatalf("expected %d, got: %d", exp, m)
+			}
+			for y := range 7 {
+				for x := range 13 {
+					img.Set(x, y, uint8((x+y)%(int(m)+1)))
+				}
+			}
+			for y := range 7 {
+				for x := range 13 {
+					if exp, v := uint8((x+y)%(int(m)+1)), img.Get(x, y); v != exp {
+						t.Errorf("(%d,%d) expected %d, got: %d", x, y, exp, v)
+					}
+					if exp, v := (color.Gray{uint8(int(img.Get(x, y)) * 255 / int(m))}), img.At(x, y); v != exp {
+						t.Errorf("(%d,%d) expected %v, got: %v", x, y, exp, v)
+					}
+				}
+			}
+			t.Logf("\n%g", img)
+			t.Logf("\n%o", img)
+		})
+	}
+	if _, err := NewGray(image.Rect(0, 0, 1, 1), 3); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+	img, _ := NewGray(image.Rect(0, 0, 4, 1), 2)
+	for x := range 4 {
+		img.Set(x, 0, uint8(x))
+	}
+	if s, exp := fmt.Sprintf("%g", img), " ░▓█"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestCanvas(t *testing.T) {
+	t.Parallel()
+	img := NewImage(image.Rect(0, 0, 16, 8))
+	c := img.Canvas()
+	var _ draw.Image = c
+	if exp, r := img.Rect, c.Bounds(); r != exp {
+		t.Fatalf("expected %v, got: %v", exp, r)
+	}
+	draw.Draw(c, image.Rect(2, 1, 14, 7), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(c, image.Rect(4, 3, 12, 5), image.Transparent, image.Point{}, draw.Src)
+	// mask with half alpha, below the default threshold
+	mask := image.NewAlpha(image.Rect(0, 0, 16, 8))
+	for i := range mask.Pix {
+		mask.Pix[i] = 0x7f
+	}
+	draw.DrawMask(c, image.Rect(0, 0, 16, 1), image.Black, image.Point{}, mask, image.Point{}, draw.Over)
+	for y := range 8 {
+		for x := range 16 {
+			exp := image.Pt(x, y).In(image.Rect(2, 1, 14, 7)) && !image.Pt(x, y).In(image.Rect(4, 3, 12, 5))
+			if b := img.Get(x, y); b != exp {
+				t.Errorf("(%d,%d) expected %t, got: %t", x, y, exp, b)
+			}
+		}
+	}
+	t.Logf("\n%v", c)
+	c.Threshold = 0x7000
+	draw.DrawMask(c, image.Rect(0, 0, 16, 1), image.Black, image.Point{}, mask, image.Point{}, draw.Over)
+	for x := range 16 {
+		if !img.Get(x, 0) {
+			t.Errorf("(%d,0) expected true", x)
+		}
+	}
+}
+
+func TestBitmapOffset(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1337))
+	img, exp := NewImage(image.Rect(10, 10, 42, 26)), NewImage(image.Rect(0, 0, 32, 16))
+	for y := 10; y < 26; y++ {
+		for x := 10; x < 42; x++ {
+			b := r.Intn(2) == 0
+			img.Set(x, y, b)
+			exp.Set(x-10, y-10, b)
+		}
+	}
+	// out of bounds
+	for _, p := range []image.Point{{9, 10}, {10, 9}, {42, 10}, {10, 26}, {0, 0}, {-1, -1}} {
+		img.Set(p.X, p.Y, true)
+		if img.Get(p.X, p.Y) {
+			t.Errorf("expected %v to be false", p)
+		}
+	}
+	if !slices.Equal(img.Pix, exp.Pix) {
+		t.Fatalf("expected:\n%b\ngot:\n%b", exp.Pix, img.Pix)
+	}
+	w, h := img.Scale()
+	if b, exp := img.Bounds(), image.Rect(10*w, 10*h, 42*w, 26*h); b != exp {
+		t.Errorf("expected %v, got: %v", exp, b)
+	}
+	for y := 10; y < 26; y++ {
+		for x := 10; x < 42; x++ {
+			if c, clr := img.At(x*w, y*h), exp.At((x-10)*w, (y-10)*h); c != clr {
+				t.Errorf("(%d,%d) expected %v, got: %v", x, y, clr, c)
+			}
+		}
+	}
+	for _, typ := range Types() {
+		if s, exp := fmt.Sprintf("%"+string(typ.Rune()), img), fmt.Sprintf("%"+string(typ.Rune()), exp); s != exp {
+			t.Errorf("%s expected:\n%s\ngot:\n%s", typ, exp, s)
+		}
+	}
+	// negative coordinates
+	neg := NewImage(image.Rect(-3, -3, 3, 3))
+	neg.Set(-3, -3, true)
+	neg.Set(2, 2, true)
+	if s, exp := fmt.Sprintf("%l", neg), "█     \n      \n      \n      \n      \n     █"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	if c := neg.At(-3*w+1, -2*h-1); c != neg.Opaque {
+		t.Errorf("expected %v, got: %v", neg.Opaque, c)
+	}
+	if c := neg.At(-1, -1); c != neg.Transparent {
+		t.Errorf("expected %v, got: %v", neg.Transparent, c)
+	}
+}
+
+func TestSubImage(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1337))
+	img := NewImage(image.Rect(3, 5, 40, 28))
+	for y := 5; y < 28; y++ {
+		for x := 3; x < 40; x++ {
+			img.Set(x, y, r.Intn(2) == 0)
+		}
+	}
+	for i := range 64 {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			x0, y0 := r.Intn(45), r.Intn(32)
+			rect := image.Rect(x0, y0, x0+r.Intn(30), y0+r.Intn(30))
+			sub := img.SubImage(rect)
+			if exp := rect.Intersect(img.Rect); sub.Rect != exp && !exp.Empty() {
+				t.Fatalf("expected %v, got: %v", exp, sub.Rect)
+			}
+			// copy
+			exp := NewImage(sub.Rect)
+			for y := sub.Rect.Min.Y; y < sub.Rect.Max.Y; y++ {
+				for x := sub.Rect.Min.X; x < sub.Rect.Max.X; x++ {
+					if b := sub.Get(x, y); b != img.Get(x, y) {
+						t.Fatalf("(%d,%d) expected %t, got: %t", x, y, img.Get(x, y), b)
+					}
+					exp.Set(x, y, img.Get(x, y))
+				}
+			}
+			for _, typ := range Types() {
+				if s, exp := fmt.Sprintf("%"+string(typ.Rune()), sub), fmt.Sprintf("%"+string(typ.Rune()), exp); s != exp {
+					t.Errorf("%s expected:\n%s\ngot:\n%s", typ, exp, s)
+				}
+			}
+			// nested
+			if !sub.Rect.Empty() {
+				p := sub.Rect.Min.Add(image.Pt(sub.Rect.Dx()/2, sub.Rect.Dy()/2))
+				nested := sub.SubImage(image.Rectangle{p, sub.Rect.Max})
+				if b := nested.Get(p.X, p.Y); b != img.Get(p.X, p.Y) {
+					t.Errorf("%v expected %t, got: %t", p, img.Get(p.X, p.Y), b)
+				}
+			}
+		})
+	}
+	// shared pixels
+	sub := img.SubImage(image.Rect(7, 9, 11, 13))
+	sub.Set(8, 10, true)
+	sub.Set(9, 10, false)
+	sub.Set(20, 20, true)
+	if !img.Get(8, 10) || img.Get(9, 10) {
+		t.Errorf("expected changes to be shared")
+	}
+	if sub.Get(20, 20) {
+		t.Errorf("expected (20,20) to be out of bounds")
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	t.Parallel()
+	img := NewImage(image.Rect(1, 2, 4, 4))
+	img.Set(1, 2, true)
+	img.Set(2, 3, true)
+	img.Set(3, 3, true)
+	tests := []struct {
+		name string
+		img  Bitmap
+		rect image.Rectangle
+		exp  string
+	}{
+		{"FlipH", img.FlipH(), image.Rect(1, 2, 4, 4), "  █\n██ "},
+		{"FlipV", img.FlipV(), image.Rect(1, 2, 4, 4), " ██\n█  "},
+		{"Rotate90", img.Rotate90(), image.Rect(2, 1, 4, 4), " █\n█ \n█ "},
+		{"Rotate180", img.Rotate180(), image.Rect(1, 2, 4, 4), "██ \n  █"},
+		{"Rotate270", img.Rotate270(), image.Rect(2, 1, 4, 4), " █\n █\n█ "},
+		{"Transpose", img.Transpose(), image.Rect(2, 1, 4, 4), "█ \n █\n █"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if test.img.Rect != test.rect {
+				t.Errorf("expected %v, got: %v", test.rect, test.img.Rect)
+			}
+			if s := fmt.Sprintf("%l", test.img); s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+	// identities
+	r := rand.New(rand.NewSource(1337))
+	src := NewImage(image.Rect(0, 0, 29, 13))
+	src.ScaleWidth, src.ScaleHeight = 3, 5
+	for y := range 13 {
+		for x := range 29 {
+			src.Set(x, y, r.Intn(2) == 0)
+		}
+	}
+	exp := fmt.Sprintf("%o", src)
+	for i, img := range []Bitmap{
+		src.FlipH().FlipH(),
+		src.FlipV().FlipV(),
+		src.Rotate90().Rotate90().Rotate90().Rotate90(),
+		src.Rotate180().Rotate180(),
+		src.Rotate90().Rotate270(),
+		src.Transpose().Transpose(),
+		src.Rotate90().FlipH().Transpose(),
+		src.FlipH().FlipV().Rotate180(),
+	} {
+		if s := fmt.Sprintf("%o", img); img.Rect != src.Rect || s != exp {
+			t.Errorf("test %d expected:\n%s\ngot:\n%s", i, exp, s)
+		}
+		if img.ScaleWidth != 3 || img.ScaleHeight != 5 {
+			t.Errorf("test %d expected scale 3, 5, got: %d, %d", i, img.ScaleWidth, img.ScaleHeight)
+		}
+	}
+}
+
+func TestOps(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1337))
+	random := func(rect image.Rectangle) Bitmap {
+		img := NewImage(rect)
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				img.Set(x, y, r.Intn(2) == 0)
+			}
+		}
+		return img
+	}
+	ops := []struct {
+		name string
+		op   Op
+		f    func(Bitmap, Bitmap) Bitmap
+		exp  func(bool, bool) bool
+	}{
+		{"Copy", OpCopy, nil, func(_, b bool) bool { return b }},
+		{"And", OpAnd, And, func(a, b bool) bool { return a && b }},
+		{"Or", OpOr, Or, func(a, b bool) bool { return a || b }},
+		{"Xor", OpXor, Xor, func(a, b bool) bool { return a != b }},
+		{"AndNot", OpAndNot, AndNot, func(a, b bool) bool { return a && !b }},
+	}
+	for i := range 32 {
+		parent := random(image.Rect(-5, 3, 40, 30))
+		a := parent.SubImage(image.Rect(r.Intn(10)-5, 3+r.Intn(10), 15+r.Intn(25), 15+r.Intn(15)))
+		b := random(image.Rect(r.Intn(40)-10, r.Intn(40)-10, 40, 40)).SubImage(image.Rect(r.Intn(20)-10, r.Intn(20)-10, 35, 35))
+		at := image.Pt(r.Intn(40)-10, r.Intn(40)-10)
+		for _, test := range ops {
+			t.Run(fmt.Sprintf("%d/%s", i, test.name), func(t *testing.T) {
+				if test.f != nil {
+					c := test.f(a, b)
+					if c.Rect != a.Rect {
+						t.Fatalf("expected %v, got: %v", a.Rect, c.Rect)
+					}
+					for y := a.Rect.Min.Y; y < a.Rect.Max.Y; y++ {
+						for x := a.Rect.Min.X; x < a.Rect.Max.X; x++ {
+							if exp, v := test.exp(a.Get(x, y), b.Get(x, y)), c.Get(x, y); v != exp {
+								t.Fatalf("(%d,%d) expected %t, got: %t", x, y, exp, v)
+							}
+						}
+					}
+				}
+				// blit into a copy of the parent, via the sub image
+				dst := parent.Clone()
+				sub := dst.SubImage(a.Rect)
+				Blit(sub, b, at, test.op)
+				for y := parent.Rect.Min.Y; y < parent.Rect.Max.Y; y++ {
+					for x := parent.Rect.Min.X; x < parent.Rect.Max.X; x++ {
+						p := image.Pt(x, y)
+						exp := parent.Get(x, y)
+						if sp := p.Sub(at).Add(b.Rect.Min); p.In(a.Rect) && sp.In(b.Rect) {
+							exp = test.exp(exp, b.Get(sp.X, sp.Y))
+						}
+						if v := dst.Get(x, y); v != exp {
+							t.Fatalf("(%d,%d) expected %t, got: %t", x, y, exp, v)
+						}
+					}
+				}
+			})
+		}
+		n := Not(a)
+		for y := a.Rect.Min.Y; y < a.Rect.Max.Y; y++ {
+			for x := a.Rect.Min.X; x < a.Rect.Max.X; x++ {
+				if n.Get(x, y) == a.Get(x, y) {
+					t.Fatalf("(%d,%d) expected %t", x, y, !a.Get(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	t.Parallel()
+	// single pixel wide diagonal line
+	img := NewImage(image.Rect(-3, 2, 61, 66))
+	for i := range 64 {
+		img.Set(i-3, i+2, true)
+	}
+	for _, test := range []struct {
+		method Resample
+		n      int
+	}{
+		{Nearest, 16},
+		{Majority, 0},
+		{Any, 16},
+	} {
+		t.Run(test.method.String(), func(t *testing.T) {
+			t.Parallel()
+			dst := img.Resize(16, 16, test.method)
+			if exp := image.Rect(0, 0, 16, 16); dst.Rect != exp {
+				t.Fatalf("expected %v, got: %v", exp, dst.Rect)
+			}
+			n := 0
+			for y := range 16 {
+				for x := range 16 {
+					if dst.Get(x, y) {
+						n++
+					}
+				}
+			}
+			if n != test.n {
+				t.Errorf("expected %d set, got: %d", test.n, n)
+			}
+			// upscaling is exact
+			up := img.Resize(128, 128, test.method)
+			for y := range 128 {
+				for x := range 128 {
+					if exp, v := img.Get(x/2-3, y/2+2), up.Get(x, y); v != exp {
+						t.Fatalf("expected (%d, %d) to be %t", x, y, exp)
+					}
+				}
+			}
+		})
+	}
+	solid := NewImage(image.Rect(0, 0, 10, 10))
+	for y := range 10 {
+		for x := range 10 {
+			solid.Set(x, y, x < 6)
+		}
+	}
+	dst := solid.Resize(2, 2, Majority)
+	if !dst.Get(0, 0) || dst.Get(1, 0) {
+		t.Errorf("expected majority left half, got:\n%s", dst)
+	}
+}
+
+func TestFit(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		w, h       int
+		cols, rows int
+		typ        Type
+		exp        image.Rectangle
+	}{
+		{640, 480, 80, 24, Octants, image.Rect(0, 0, 128, 96)},
+		{100, 50, 80, 24, Octants, image.Rect(0, 0, 100, 50)},
+		{1000, 100, 80, 24, Octants, image.Rect(0, 0, 160, 16)},
+		{100, 100, 80, 24, Solids, image.Rect(0, 0, 24, 24)},
+		{100, 100, 80, 24, Doubles, image.Rect(0, 0, 24, 24)},
+		{300, 30, 80, 24, Doubles, image.Rect(0, 0, 40, 4)},
+		{64, 64, 10, 10, Braille, image.Rect(0, 0, 20, 20)},
+	} {
+		t.Run(fmt.Sprintf("%dx%d/%s", test.w, test.h, test.typ), func(t *testing.T) {
+			t.Parallel()
+			img := NewImage(image.Rect(0, 0, test.w, test.h))
+			if dst := img.Fit(test.cols, test.rows, test.typ); dst.Rect != test.exp {
+				t.Errorf("expected %v, got: %v", test.exp, dst.Rect)
+			}
+		})
+	}
+}
+
+func TestAspect(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		typ  Type
+		cell float64
+		exp  float64
+	}{
+		{Solids, 0, 2},
+		{Doubles, 0, 1},
+		{Halves, 0, 1},
+		{Quads, 0, 2},
+		{Sextants, 0, 4.0 / 3},
+		{Octants, 0, 1},
+		{Braille, 0, 1},
+		{Solids, 1, 1},
+		{Octants, 2.5, 1.25},
+		{Auto, 0, 0},
+	} {
+		if v := test.typ.Aspect(test.cell); v != test.exp {
+			t.Errorf("%s %g: expected %g, got: %g", test.typ, test.cell, test.exp, v)
+		}
+	}
+	img := NewImage(image.Rect(0, 0, 12, 12))
+	for i := range 12 {
+		img.Set(i, i, true)
+		img.Set(11-i, i, true)
+	}
+	for _, typ := range Types() {
+		t.Run(typ.String(), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := img.EncodeAspect(&buf, typ, 0); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			cols := utf8.RuneCountInString(lines[0])
+			if typ == Emojis {
+				// double wide runes
+				cols *= 2
+			}
+			if exp := 2 * len(lines); cols != exp {
+				t.Errorf("expected %d columns for %d rows, got: %d", exp, len(lines), cols)
+			}
+		})
+	}
+}
+
+func TestEncodeAspectCapabilities(t *testing.T) {
+	// not parallel, as the default capabilities are global
+	c := DefaultCapabilities
+	t.Cleanup(func() {
+		DefaultCapabilities = c
+	})
+	DefaultCapabilities = Capabilities{Braille: true}
+	img := NewImage(image.Rect(0, 0, 32, 32))
+	for i := range 32 {
+		img.Set(i, i, true)
+	}
+	var buf, exp bytes.Buffer
+	if err := img.EncodeAspect(&buf, Auto, 0); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := img.EncodeAspect(&exp, Braille, 0); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, exp := buf.String(), exp.String(); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+	none := Capabilities{}
+	for _, typ := range []Type{Solids, Doubles, Halves, Quads} {
+		if !none.Supports(typ) {
+			t.Errorf("expected %s to be supported", typ)
+		}
+	}
+	for _, typ := range []Type{Sextants, SextantsSeparated, QuadsSeparated, Octants, Braille, Auto} {
+		if none.Supports(typ) {
+			t.Errorf("expected %s to not be supported", typ)
+		}
+	}
+	for i, test := range []struct {
+		c    Capabilities
+		x, y int
+		exp  Type
+	}{
+		{DefaultCapabilities, 10, 1, Octants},
+		{DefaultCapabilities, 10, 10, Octants},
+		{DefaultCapabilities, 10, 30, Octants},
+		{Capabilities{Sextants: true}, 10, 1, Sextants},
+		{Capabilities{Sextants: true, MaxRows: 3}, 10, 10, Sextants},
+		{Capabilities{Braille: true, Sextants: true}, 10, 30, Braille},
+		{Capabilities{Sextants: true}, 10, 30, Sextants},
+		{none, 10, 30, Quads},
+		{Capabilities{Octants: true, MaxRows: 3}, 10, 10, Octants},
+		{Capabilities{Braille: true, MaxRows: 3}, 10, 10, Braille},
+		{Capabilities{MaxRows: 3}, 10, 10, Quads},
+		{Capabilities{MaxCols: 80}, 100, 2, Quads},
+		{Capabilities{MaxCols: 80}, 200, 2, Quads},
+		{Capabilities{MaxCols: 80, Sextants: true, Octants: true}, 200, 2, Octants},
+	} {
+		if typ := test.c.Best(test.x, test.y); typ != test.exp {
+			t.Errorf("test %d expected %s, got: %s", i, test.exp, typ)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+	all := Capabilities{Braille: true, Sextants: true, Octants: true, Separated: true}
+	for i, test := range []struct {
+		env map[string]string
+		exp Capabilities
+	}{
+		{nil, Capabilities{ASCII: true}},
+		{map[string]string{"TERM": "dumb", "LANG": "en_US.UTF-8"}, Capabilities{ASCII: true}},
+		{map[string]string{"TERM": "xterm-256color", "LANG": "C"}, Capabilities{ASCII: true, Color: Color256}},
+		{map[string]string{"TERM": "xterm-256color", "LANG": "en_US.UTF-8", "LC_ALL": "C"}, Capabilities{ASCII: true, Color: Color256}},
+		{map[string]string{"TERM": "xterm", "LANG": "en_US.UTF-8"}, Capabilities{Braille: true, Color: Color16}},
+		{map[string]string{"TERM": "xterm-256color", "LC_CTYPE": "en_US.utf8", "COLORTERM": "truecolor"}, Capabilities{Braille: true, Color: ColorTrue}},
+		{map[string]string{"TERM": "linux", "LANG": "en_US.UTF-8"}, Capabilities{Color: Color16}},
+		{map[string]string{"TERM": "xterm-kitty", "LANG": "en_US.UTF-8"}, with(all, ColorTrue)},
+		{map[string]string{"TERM": "foot", "LANG": "en_US.UTF-8", "NO_COLOR": "1"}, all},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "WezTerm", "LANG": "en_US.UTF-8"}, with(all, ColorTrue)},
+		{map[string]string{"TERM": "xterm-ghostty", "LANG": "en_US.UTF-8", "COLORTERM": "truecolor"}, with(all, ColorTrue)},
+	} {
+		c := DetectFunc(func(k string) string { return test.env[k] })
+		if c != test.exp {
+			t.Errorf("test %d expected %+v, got: %+v", i, test.exp, c)
+		}
+	}
+	ascii := Capabilities{ASCII: true}
+	for _, typ := range []Type{Solids, Halves, Quads, Octants, Braille} {
+		if ascii.Supports(typ) {
+			t.Errorf("expected %s to not be supported", typ)
+		}
+	}
+	for _, y := range []int{1, 30} {
+		if typ := ascii.Best(10, y); typ != ASCIIs {
+			t.Errorf("expected %s, got: %s", ASCIIs, typ)
+		}
+	}
+}
+
+func with(c Capabilities, mode ColorMode) Capabilities {
+	c.Color = mode
+	return c
+}
+
+func TestRegister(t *testing.T) {
+	// not parallel, as registered types are global
+	t.Cleanup(func() {
+		registeredMu.Lock()
+		defer registeredMu.Unlock()
+		blocksMu.Lock()
+		defer blocksMu.Unlock()
+		indexesMu.Lock()
+		defer indexesMu.Unlock()
+		for _, typ := range customOrder {
+			delete(registered, typ)
+			delete(blocks, typ)
+			delete(indexes, typ)
+		}
+		customOrder = nil
+	})
+	for i, test := range []struct {
+		verb  rune
+		name  string
+		w, h  int
+		runes []rune
+		exp   error
+	}{
+		{'h', "Hashes", 1, 1, []rune{'.', '#'}, nil},
+		{'k', "Wides", 2, 1, []rune{'.', ':', '\'', '#'}, nil},
+		{'j', "Circles", 0, 2, []rune{'○', '◓', '◒', '●'}, nil},
+		{'h', "Hashes", 1, 1, []rune{'.', '#'}, ErrTypeExists},
+		{'l', "Solids", 1, 1, []rune{'.', '#'}, ErrTypeExists},
+		{'v', "Auto", 1, 1, []rune{'.', '#'}, ErrTypeExists},
+		{'s', "String", 1, 1, []rune{'.', '#'}, ErrTypeExists},
+		{'1', "One", 1, 1, []rune{'.', '#'}, ErrInvalidType},
+		{'w', "Wraps", 1, 1, []rune{'.', '#'}, ErrInvalidType},
+		{'y', "", 1, 1, []rune{'.', '#'}, ErrInvalidType},
+		{'y', "Y", 1, 1, []rune{'.', '#', '+'}, ErrInvalidType},
+		{'y', "Y", 1, 1, []rune{'#', '#'}, ErrInvalidType},
+		{'y', "Y", 1, 1, []rune{'.', '\n'}, ErrInvalidType},
+		{'y', "Y", -1, 1, []rune{'.', '#'}, ErrInvalidType},
+		{'y', "Y", 0, 1, []rune{'.'}, ErrInvalidType},
+		{'y', "Y", 3, 3, make([]rune, 512), ErrInvalidType},
+	} {
+		if err := Register(test.verb, test.name, test.w, test.h, test.runes); !errors.Is(err, test.exp) {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, err)
+		}
+	}
+	hashes, wides, circles := Type('h'), Type('k'), Type('j')
+	if types := Types(); !slices.Equal(types[len(types)-3:], []Type{hashes, wides, circles}) {
+		t.Errorf("expected registered types in %v", types)
+	}
+	if s := hashes.String(); s != "Hashes" {
+		t.Errorf("expected %q, got: %q", "Hashes", s)
+	}
+	if w, h, n := wides.Width(), wides.Height(), wides.RuneCount(); w != 2 || h != 1 || n != 4 {
+		t.Errorf("expected 2x1 with 4 runes, got: %dx%d with %d runes", w, h, n)
+	}
+	// double wide
+	if w := NewImage(image.Rect(0, 0, 10, 1)).Width(circles); w != 20 {
+		t.Errorf("expected 20, got: %d", w)
+	}
+	if c := (Capabilities{MaxCols: 15}); c.Fits(circles, 10, 1) || !c.Fits(circles, 7, 1) {
+		t.Errorf("expected double wide columns")
+	}
+	if !DefaultCapabilities.Supports(hashes) || !(Capabilities{ASCII: true}).Supports(wides) {
+		t.Errorf("expected registered types to be supported")
+	}
+	img := NewImage(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, true)
+	img.Set(1, 0, true)
+	img.Set(3, 0, true)
+	img.Set(2, 1, true)
+	for _, test := range []struct {
+		typ Type
+		exp string
+	}{
+		{hashes, "##.#\n..#."},
+		{wides, "#'\n.:"},
+		{circles, "◓◓◒◓"},
+	} {
+		s := fmt.Sprintf("%"+string(test.typ.Rune()), img)
+		if s != test.exp {
+			t.Errorf("%s expected %q, got: %q", test.typ, test.exp, s)
+		}
+		var buf bytes.Buffer
+		test.typ.Dump(&buf)
+		if buf.Len() == 0 {
+			t.Errorf("%s expected dump", test.typ)
+		}
+		dec, err := Decode(strings.NewReader(s), test.typ)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !bytes.Equal(dec.Pix, img.Pix) {
+			t.Errorf("%s expected %v, got: %v", test.typ, img.Pix, dec.Pix)
+		}
+	}
+	if typ, ok := Sniff([]byte("#.#\n..#")); !ok || typ != hashes {
+		t.Errorf("expected %s, got: %s", hashes, typ)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+	for seed := 1330; seed <= 1343; seed++ {
+		img := newTestBitmap(seed)
+		for _, typ := range Types() {
+			t.Run(fmt.Sprintf("%d/%s", seed, typ), func(t *testing.T) {
+				t.Parallel()
+				var exp, buf bytes.Buffer
+				if err := img.Encode(&exp, typ); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				enc := NewEncoder(&buf, typ, img.Rect.Dx())
+				row := make([]bool, img.Rect.Dx()+3)
+				for y := range img.Rect.Dy() {
+					for x := range row {
+						row[x] = img.Get(x, y)
+					}
+					if err := enc.WriteRow(row); err != nil {
+						t.Fatalf("expected no error, got: %v", err)
+					}
+					// complete bands are written immediately, terminated by a
+					// newline
+					if lines := (y + 1) / typ.Height(); strings.Count(buf.String(), "\n") != lines {
+						t.Fatalf("row %d: expected %d lines, got:\n%s", y, lines, buf.String())
+					}
+				}
+				if err := enc.Close(); err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				if s, exp := buf.String(), exp.String()+"\n"; s != exp {
+					t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+				}
+			})
+		}
+	}
+	if err := NewEncoder(io.Discard, Type('!'), 10).WriteRow(nil); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected %v, got: %v", ErrUnknownType, err)
+	}
+	// unbounded height
+	if h := NewEncoder(io.Discard, Auto, 10).band.Rect.Dy(); h != Octants.Height() {
+		t.Errorf("expected %d, got: %d", Octants.Height(), h)
+	}
+	if c := (Capabilities{MaxRows: 10}); c.Fits(Octants, 10, math.MaxInt) {
+		t.Errorf("expected unbounded height to not fit")
+	}
+}
+
+func TestBlitOverlap(t *testing.T) {
+	t.Parallel()
+	img := NewImage(image.Rect(0, 0, 4, 4))
+	img.Set(0, 0, true)
+	Blit(img, img, image.Pt(0, 1), OpCopy)
+	if s, exp := fmt.Sprintf("%L", img), "X   \nX   \n    \n    "; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	r := rand.New(rand.NewSource(1337))
+	for i := range 32 {
+		dst := NewImage(image.Rect(0, 0, 30, 30))
+		for y := range 30 {
+			for x := range 30 {
+				dst.Set(x, y, r.Intn(2) == 0)
+			}
+		}
+		src := dst.SubImage(image.Rect(r.Intn(15), r.Intn(15), 15+r.Intn(15), 15+r.Intn(15)))
+		at := image.Pt(r.Intn(20)-5, r.Intn(20)-5)
+		exp := dst.Clone()
+		Blit(exp, src.Clone(), at, OpXor)
+		Blit(dst, src, at, OpXor)
+		if s, exp := fmt.Sprintf("%L", dst), fmt.Sprintf("%L", exp); s != exp {
+			t.Errorf("%d expected:\n%s\ngot:\n%s", i, exp, s)
+		}
+	}
+}
+
+func TestBitOrder(t *testing.T) {
+	t.Parallel()
+	img, err := NewBytes([]byte{0x80, 0x01}, 8, 2, WithBitOrder(MSBFirst))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if img.Order != MSBFirst {
+		t.Errorf("expected %s, got: %s", MSBFirst, img.Order)
+	}
+	if s, exp := fmt.Sprintf("%l", img), "█       \n       █"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	img.Set(1, 0, true)
+	if img.Pix[0] != 0xc0 {
+		t.Errorf("expected %02x, got: %02x", 0xc0, img.Pix[0])
+	}
+	// unused bits of last byte
+	img, err = NewBytes([]byte{0xff}, 3, 1, WithBitOrder(MSBFirst))
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case img.Pix[0] != 0xe0:
+		t.Errorf("expected %02x, got: %02x", 0xe0, img.Pix[0])
+	}
+	// reversed bits are the same image
+	r := rand.New(rand.NewSource(1337))
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = uint8(r.Intn(256))
+	}
+	rev := make([]byte, len(data))
+	for i, b := range data {
+		for j := range 8 {
+			rev[i] |= (b >> j & 1) << (7 - j)
+		}
+	}
+	lsb, err := New(data, 13)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	msb, err := New(rev, 13, WithBitOrder(MSBFirst))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, exp := fmt.Sprintf("%o", msb), fmt.Sprintf("%o", lsb); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+	// ops, transforms and sub images preserve bit order
+	sub := msb.SubImage(image.Rect(3, 2, 12, 30))
+	for name, v := range map[string]Bitmap{
+		"Clone":    sub.Clone(),
+		"Not":      Not(Not(sub)),
+		"And":      And(sub, lsb),
+		"Or":       Or(sub, NewImage(lsb.Rect)),
+		"FlipH":    sub.FlipH().FlipH(),
+		"Rotate90": sub.Rotate90().Rotate270(),
+	} {
+		if v.Order != MSBFirst {
+			t.Errorf("%s expected %s, got: %s", name, MSBFirst, v.Order)
+		}
+		if s, exp := fmt.Sprintf("%l", v), fmt.Sprintf("%l", lsb.SubImage(sub.Rect)); s != exp {
+			t.Errorf("%s expected:\n%s\ngot:\n%s", name, exp, s)
+		}
+	}
+	// mixed bit orders
+	dst := NewImage(image.Rect(0, 0, 20, 40))
+	Blit(dst, sub, image.Pt(5, 1), OpCopy)
+	for y := range 28 {
+		for x := range 9 {
+			if b, exp := dst.Get(5+x, 1+y), lsb.Get(3+x, 2+y); b != exp {
+				t.Errorf("(%d,%d) expected %t, got: %t", x, y, exp, b)
+			}
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+	exp := newTestBitmap(1337)
+	x, y := exp.Rect.Dx(), exp.Rect.Dy()
+	for _, align := range []int{0, 8, 16, 32} {
+		for _, order := range []BitOrder{LSBFirst, MSBFirst} {
+			t.Run(fmt.Sprintf("%d/%s", align, order), func(t *testing.T) {
+				t.Parallel()
+				stride := AlignStride(x, align)
+				if stride < x || align != 0 && stride%align != 0 {
+					t.Fatalf("expected %d aligned stride, got: %d", align, stride)
+				}
+				data := make([]byte, (stride*y+7)/8)
+				for j := range y {
+					for i := range x {
+						if n := j*stride + i; exp.Get(i, j) && order == MSBFirst {
+							data[n/8] |= 0x80 >> (n % 8)
+						} else if exp.Get(i, j) {
+							data[n/8] |= 1 << (n % 8)
+						}
+					}
+				}
+				img, err := Wrap(data, x, y, stride, WithBitOrder(order))
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				cpy, err := NewBytes(data, x, y, WithBitOrder(order), WithAlign(align))
+				if err != nil {
+					t.Fatalf("expected no error, got: %v", err)
+				}
+				for _, v := range []Bitmap{img, cpy, img.Clone(), img.Rotate90().Rotate270(), Or(img, img)} {
+					for _, typ := range Types() {
+						if s, exp := fmt.Sprintf("%"+string(typ.Rune()), v), fmt.Sprintf("%"+string(typ.Rune()), exp); s != exp {
+							t.Errorf("%s expected:\n%s\ngot:\n%s", typ, exp, s)
+						}
+					}
+				}
+				// zero copy
+				v := !exp.Get(x-1, y-1)
+				img.Set(x-1, y-1, v)
+				n := (y-1)*stride + x - 1
+				if b := data[n/8]&img.mask(n) != 0; b != v {
+					t.Errorf("expected %t, got: %t", v, b)
+				}
+			})
+		}
+	}
+	if _, err := Wrap(make([]byte, 3), 8, 4, 8); !errors.Is(err, io.ErrShortBuffer) {
+		t.Errorf("expected %v, got: %v", io.ErrShortBuffer, err)
+	}
+	if _, err := Wrap(make([]byte, 4), 8, 4, 7); err == nil {
+		t.Errorf("expected error")
+	}
+	// last row does not need padding
+	if _, err := Wrap(make([]byte, 7), 20, 2, 32); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
+
+func TestPaged(t *testing.T) {
+	t.Parallel()
+	// 4x10, with a vertical line in column 0, and the top and bottom rows set
+	data := []byte{0xff, 0x01, 0x01, 0x01, 0x03, 0x02, 0x02, 0x02}
+	img, err := NewPaged(data, 4, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := "████\n█   \n█   \n█   \n█   \n█   \n█   \n█   \n█   \n████"
+	if s := fmt.Sprintf("%l", img); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+	if buf := img.Paged(); !bytes.Equal(buf, data) {
+		t.Errorf("expected %x, got: %x", data, buf)
+	}
+	rev := make([]byte, len(data))
+	for i, b := range data {
+		rev[i] = bits.Reverse8(b)
+	}
+	if img, err = NewPaged(rev, 4, 10, WithBitOrder(MSBFirst)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s := fmt.Sprintf("%l", img); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+	if buf := img.Paged(WithBitOrder(MSBFirst)); !bytes.Equal(buf, rev) {
+		t.Errorf("expected %x, got: %x", rev, buf)
+	}
+	// round trip
+	for seed := 1330; seed <= 1343; seed++ {
+		exp := newTestBitmap(seed)
+		img, err := NewPaged(exp.Paged(), exp.Rect.Dx(), exp.Rect.Dy())
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if s, exp := fmt.Sprintf("%o", img), fmt.Sprintf("%o", exp); s != exp {
+			t.Errorf("%d expected:\n%s\ngot:\n%s", seed, exp, s)
+		}
+	}
+	if _, err := NewPaged(data, 4, 17); !errors.Is(err, io.ErrShortBuffer) {
+		t.Errorf("expected %v, got: %v", io.ErrShortBuffer, err)
+	}
+	for _, size := range []image.Point{{-2, 3}, {2, -3}} {
+		if _, err := NewPaged(nil, size.X, size.Y); err == nil {
+			t.Errorf("%v expected error", size)
+		}
+	}
+}
+
+func TestWordOrder(t *testing.T) {
+	t.Parallel()
+	glyph := []uint16{
+		0b1000000000000001,
+		0b0100000000000010,
+		0b0000000011111111,
+	}
+	img, err := New(glyph, 16, WithWordOrder(MSBFirst))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp := "X              X\n X            X \n        XXXXXXXX"
+	if s := fmt.Sprintf("%L", img); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+	if img, err = New(glyph, 16, WithWordOrder(LSBFirst)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	exp = "X              X\n X            X \nXXXXXXXX        "
+	if s := fmt.Sprintf("%L", img); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+	// byte order
+	for _, test := range []struct {
+		byteOrder binary.ByteOrder
+		exp       string
+	}{
+		{binary.BigEndian, "        X       "},
+		{binary.LittleEndian, "X               "},
+	} {
+		img, err := New([]uint16{0x0001}, 16, WithByteOrder(test.byteOrder))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if s := fmt.Sprintf("%L", img); s != test.exp {
+			t.Errorf("%v expected %q, got: %q", test.byteOrder, test.exp, s)
+		}
+	}
+}
+
+func TestPBM(t *testing.T) {
+	t.Parallel()
+	img := NewImage(image.Rect(2, 3, 12, 5))
+	img.Set(2, 3, true)
+	img.Set(11, 3, true)
+	img.Set(10, 4, true)
+	for _, test := range []struct {
+		plain bool
+		exp   string
+	}{
+		{true, "P1\n10 2\n1000000001\n0000000010\n"},
+		{false, "P4\n10 2\n\x80\x40\x00\x80"},
+	} {
+		var buf bytes.Buffer
+		if err := EncodePBM(&buf, img, test.plain); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if s := buf.String(); s != test.exp {
+			t.Errorf("expected %q, got: %q", test.exp, s)
+		}
+	}
+	// round trip
+	for seed := 1330; seed <= 1343; seed++ {
+		exp := newTestBitmap(seed)
+		for _, plain := range []bool{true, false} {
+			var buf bytes.Buffer
+			if err := EncodePBM(&buf, exp, plain); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			cfg, name, err := image.DecodeConfig(bytes.NewReader(buf.Bytes()))
+			switch {
+			case err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			case name != "pbm":
+				t.Errorf("expected pbm, got: %s", name)
+			case cfg.Width != exp.Rect.Dx() || cfg.Height != exp.Rect.Dy():
+				t.Errorf("expected %dx%d, got: %dx%d", exp.Rect.Dx(), exp.Rect.Dy(), cfg.Width, cfg.Height)
+			}
+			v, _, err := image.Decode(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if v.Bounds() != exp.Rect {
+				t.Errorf("expected %v, got: %v", exp.Rect, v.Bounds())
+			}
+			dec, err := DecodePBM(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if s, exp := fmt.Sprintf("%o", dec), fmt.Sprintf("%o", exp); s != exp {
+				t.Errorf("%d expected:\n%s\ngot:\n%s", seed, exp, s)
+			}
+		}
+	}
+	// set pixels are black
+	for _, s := range []string{"P1\n2 1\n10\n", "P4\n2 1\n\x80"} {
+		v, _, err := image.Decode(strings.NewReader(s))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		for x, exp := range []uint8{0, 255} {
+			if c := color.GrayModel.Convert(v.At(x, 0)).(color.Gray); c.Y != exp {
+				t.Errorf("%q (%d,0) expected %d, got: %d", s, x, exp, c.Y)
+			}
+		}
+		cfg, _, err := image.DecodeConfig(strings.NewReader(s))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if p, ok := cfg.ColorModel.(color.Palette); !ok || !slices.Equal(p, v.ColorModel().(color.Palette)) {
+			t.Errorf("%q expected %v, got: %v", s, v.ColorModel(), cfg.ColorModel)
+		}
+	}
+	// comments and unseparated plain pixels
+	dec, err := DecodePBM(strings.NewReader("P1\n# comment\n4 # width\n2\n1001\n01 1 0"))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, exp := fmt.Sprintf("%L", dec), "X  X\n XX "; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	for _, s := range []string{
+		"P3\n1 1\n1",
+		"P1\n2 2\n1 0 2 1",
+		"P1 x 1\n1",
+	} {
+		if _, err := DecodePBM(strings.NewReader(s)); !errors.Is(err, ErrInvalidNetpbm) {
+			t.Errorf("%q expected %v, got: %v", s, ErrInvalidNetpbm, err)
+		}
+	}
+	if _, err := DecodePBM(strings.NewReader("P4\n9 2\n\x00\x00\x00")); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected %v, got: %v", io.ErrUnexpectedEOF, err)
+	}
+	// hostile headers
+	for _, s := range []string{
+		"P4\n16000000 16000000\n\x00",
+		"P1\n16000000 16000000\n0",
+		"P5\n16000000 16000000\n255\n\x00",
+		"P2\n16000000 16000000\n255\n0",
+	} {
+		if _, _, err := image.Decode(strings.NewReader(s)); !errors.Is(err, ErrInvalidNetpbm) {
+			t.Errorf("%q expected %v, got: %v", s, ErrInvalidNetpbm, err)
+		}
+	}
+	// large, truncated images
+	for _, s := range []string{
+		"P4\n8000 8000\n\x00",
+		"P1\n8000 8000\n0",
+		"P5\n8000 8000\n255\n\x00",
+		"P2\n8000 8000\n255\n0",
+	} {
+		if _, _, err := image.Decode(strings.NewReader(s)); !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%q expected EOF, got: %v", s, err)
+		}
+	}
+}
+
+func TestPGM(t *testing.T) {
+	t.Parallel()
+	src := image.NewGray(image.Rect(0, 0, 40, 3))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 7)
+	}
+	for _, plain := range []bool{true, false} {
+		var buf bytes.Buffer
+		if err := EncodePGM(&buf, src, plain); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if plain && 70 < len(line) {
+				t.Errorf("expected line length <= 70, got: %d", len(line))
+			}
+		}
+		v, name, err := image.Decode(bytes.NewReader(buf.Bytes()))
+		switch {
+		case err != nil:
+			t.Fatalf("expected no error, got: %v", err)
+		case name != "pgm":
+			t.Errorf("expected pgm, got: %s", name)
+		}
+		if gray := v.(GrayBitmap).Gray(); !bytes.Equal(gray.Pix, src.Pix) {
+			t.Errorf("expected %v, got: %v", src.Pix, gray.Pix)
+		}
+	}
+	// scaled levels
+	for _, test := range []struct {
+		s   string
+		exp []uint8
+	}{
+		{"P2\n3 1\n15\n0 15 5", []uint8{0, 255, 85}},
+		{"P5\n2 1\n65535\n\xff\xff\x80\x00", []uint8{255, 128}},
+	} {
+		img, err := DecodePGM(strings.NewReader(test.s))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if gray := img.Gray(); !bytes.Equal(gray.Pix, test.exp) {
+			t.Errorf("expected %v, got: %v", test.exp, gray.Pix)
+		}
+	}
+	if _, err := DecodePGM(strings.NewReader("P2\n1 1\n15\n16")); !errors.Is(err, ErrInvalidNetpbm) {
+		t.Errorf("expected %v, got: %v", ErrInvalidNetpbm, err)
+	}
+}
+
 type oneReader struct{}
 
 func newOneReader(x, y int) io.Reader {
@@ -220,6 +1703,95 @@ func (r *oneReader) Read(buf []byte) (int, error) {
 	return n, nil
 }
 
+// voidAndCluster builds a size x size blue noise ordered ditherer using
+// Ulichney's void-and-cluster method, with a gaussian filter of sigma.
+//
+// See: https://doi.org/10.1117/12.152707
+func voidAndCluster(size int, sigma float64) Ordered {
+	n := size * size
+	// toroidal gaussian filter
+	filter := make([]float64, n)
+	for y := range size {
+		for x := range size {
+			dx, dy := float64(min(x, size-x)), float64(min(y, size-y))
+			filter[y*size+x] = math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+		}
+	}
+	pattern, energy := make([]bool, n), make([]float64, n)
+	toggle := func(i int, b bool) {
+		pattern[i] = b
+		f := 1.0
+		if !b {
+			f = -1.0
+		}
+		ix, iy := i%size, i/size
+		for j := range n {
+			dx, dy := (j%size-ix+size)%size, (j/size-iy+size)%size
+			energy[j] += f * filter[dy*size+dx]
+		}
+	}
+	// find returns the tightest cluster (highest energy set pixel) or the
+	// largest void (lowest energy unset pixel)
+	find := func(b bool) int {
+		k := -1
+		for i, v := range pattern {
+			if v == b && (k == -1 || (b && energy[k] < energy[i]) || (!b && energy[i] < energy[k])) {
+				k = i
+			}
+		}
+		return k
+	}
+	// initial binary pattern
+	r := rand.New(rand.NewSource(int64(n)))
+	ones := n / 10
+	for _, i := range r.Perm(n)[:ones] {
+		toggle(i, true)
+	}
+	for {
+		i := find(true)
+		toggle(i, false)
+		j := find(false)
+		if i == j {
+			toggle(i, true)
+			break
+		}
+		toggle(j, true)
+	}
+	initial, initialEnergy := append([]bool(nil), pattern...), append([]float64(nil), energy...)
+	m := make([]int, n)
+	// phase 1: rank set pixels, removing the tightest clusters
+	for rank := ones - 1; 0 <= rank; rank-- {
+		i := find(true)
+		toggle(i, false)
+		m[i] = rank
+	}
+	// phase 2 and 3: rank unset pixels, filling the largest voids
+	copy(pattern, initial)
+	copy(energy, initialEnergy)
+	for rank := ones; rank < n; rank++ {
+		i := find(false)
+		toggle(i, true)
+		m[i] = rank
+	}
+	return Ordered{
+		Size: size,
+		Map:  m,
+	}
+}
+
+// newTestBitmap creates a random bitmap for the seed, as generated in
+// [TestBitmap].
+func newTestBitmap(seed int) Bitmap {
+	r := rand.New(rand.NewSource(int64(seed)))
+	img := NewImage(image.Rect(0, 0, 1+r.Intn(28), 1+r.Intn(28)))
+	for y := range img.Rect.Dy() {
+		for x := range img.Rect.Dx() {
+			img.Set(x, y, r.Intn(3) != 0)
+		}
+	}
+	return img
+}
+
 func testWrite(t *testing.T, buf []byte) {
 	t.Helper()
 	for _, line := range bytes.Split(buf, []byte{'\n'}) {
@@ -25,6 +25,26 @@ func XXsRunes() []rune {
 	}
 }
 
+// ShadesRunes returns the runes for single block resolution grayscale images,
+// from lightest to darkest.
+//
+// See: https://www.amp-what.com/unicode/search/shade
+func ShadesRunes() []rune {
+	return []rune{
+		' ', '░', '▒', '▓', '█',
+	}
+}
+
+// EmojisRunes returns the runes for single block resolution bitmaps using
+// double wide emoji squares.
+//
+// See: https://www.amp-what.com/unicode/search/large%20square
+func EmojisRunes() []rune {
+	return []rune{
+		'⬜', '⬛',
+	}
+}
+
 // HalvesRunes returns the runes for double block resolution bitmaps.
 //
 // See: https://www.amp-what.com/unicode/search/half%20block
@@ -0,0 +1,179 @@
+package blocked
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultCapabilities are the default terminal capabilities used by
+// [Bitmap.Best], and by the [Auto] block type.
+var DefaultCapabilities = Capabilities{
+	Braille:   true,
+	Sextants:  true,
+	Octants:   true,
+	Separated: true,
+}
+
+// Capabilities describes the glyph sets and the display size available on a
+// terminal.
+type Capabilities struct {
+	// ASCII indicates only ASCII glyphs are available.
+	ASCII bool
+	// Braille indicates the Braille Patterns block is available.
+	Braille bool
+	// Sextants indicates the sextant glyphs from the Symbols for Legacy
+	// Computing block are available.
+	Sextants bool
+	// Octants indicates the octant glyphs from the Symbols for Legacy
+	// Computing Supplement block are available.
+	Octants bool
+	// Separated indicates the separated quadrant and sextant glyphs from the
+	// Symbols for Legacy Computing Supplement block are available.
+	Separated bool
+	// MaxCols is the maximum number of columns, or 0 when unlimited.
+	MaxCols int
+	// MaxRows is the maximum number of rows, or 0 when unlimited.
+	MaxRows int
+	// Color is the color mode.
+	Color ColorMode
+}
+
+// Detect detects the terminal capabilities from the environment. See
+// [DetectFunc].
+func Detect() Capabilities {
+	return DetectFunc(os.Getenv)
+}
+
+// DetectFunc detects the terminal capabilities using the getenv func to
+// inspect the TERM, COLORTERM, TERM_PROGRAM, LC_ALL, LC_CTYPE, LANG and
+// NO_COLOR environment variables.
+//
+// Only ASCII glyphs are available for non UTF-8 locales and the dumb
+// terminal. The Symbols for Legacy Computing glyphs are available for
+// terminals known to support them (kitty, foot, Ghostty and WezTerm), and
+// otherwise Braille is preferred.
+func DetectFunc(getenv func(string) string) Capabilities {
+	term, prog := getenv("TERM"), strings.ToLower(getenv("TERM_PROGRAM"))
+	var c Capabilities
+	switch {
+	case term == "" || term == "dumb":
+		c.ASCII = true
+	case prog == "ghostty" || prog == "wezterm",
+		strings.HasPrefix(term, "xterm-kitty"),
+		strings.HasPrefix(term, "xterm-ghostty"),
+		strings.HasPrefix(term, "foot"),
+		strings.HasPrefix(term, "wezterm"):
+		c.Braille, c.Sextants, c.Octants, c.Separated = true, true, true, true
+	case term == "linux" || strings.HasPrefix(term, "vt"):
+		// console fonts lack Braille and Legacy Computing glyphs
+	default:
+		c.Braille = true
+	}
+	if !isUTF8(getenv) {
+		c = Capabilities{ASCII: true}
+	}
+	switch colorterm := strings.ToLower(getenv("COLORTERM")); {
+	case getenv("NO_COLOR") != "", term == "" || term == "dumb":
+	case colorterm == "truecolor" || colorterm == "24bit",
+		prog == "ghostty" || prog == "wezterm" || prog == "iterm.app",
+		strings.HasPrefix(term, "xterm-kitty"),
+		strings.HasPrefix(term, "xterm-ghostty"):
+		c.Color = ColorTrue
+	case strings.Contains(term, "256color"):
+		c.Color = Color256
+	default:
+		c.Color = Color16
+	}
+	return c
+}
+
+// isUTF8 returns true when the locale from the environment is UTF-8.
+func isUTF8(getenv func(string) string) bool {
+	for _, k := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := strings.ToLower(getenv(k)); v != "" {
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
+
+// Supports returns true when the block type's glyphs are available.
+func (c Capabilities) Supports(typ Type) bool {
+	if c.ASCII && typ.builtin() {
+		return typ == Binaries || typ == XXs || typ == ASCIIs
+	}
+	switch typ {
+	case Solids, Binaries, XXs, Shades, Doubles, Emojis, Halves, ASCIIs, Quads:
+		return true
+	case Sextants:
+		return c.Sextants
+	case Octants:
+		return c.Octants
+	case Braille:
+		return c.Braille
+	case QuadsSeparated:
+		return c.Separated
+	case SextantsSeparated:
+		return c.Separated && c.Sextants
+	}
+	if r, ok := lookup(typ); ok {
+		// registered block types are supported when not limited to ASCII
+		return !c.ASCII || !slices.ContainsFunc(r.runes, func(r rune) bool {
+			return r > unicode.MaxASCII
+		})
+	}
+	return false
+}
+
+// Fits returns true when a x by y pixel bitmap encoded using the block type
+// fits within the maximum columns and rows.
+func (c Capabilities) Fits(typ Type, x, y int) bool {
+	w, h := typ.Width(), typ.Height()
+	if w < 0 || h <= 0 {
+		return false
+	}
+	cols := 2 * x
+	if w != 0 {
+		cols = ceilDiv(x, w)
+	}
+	return (c.MaxCols == 0 || cols <= c.MaxCols) &&
+		(c.MaxRows == 0 || ceilDiv(y, h) <= c.MaxRows)
+}
+
+// ceilDiv returns x / y for non-negative x, rounded up without overflowing.
+func ceilDiv(x, y int) int {
+	if x <= 0 {
+		return 0
+	}
+	return (x-1)/y + 1
+}
+
+// Best returns the best supported block type for a x by y pixel bitmap, being
+// the densest supported block type that fits within the maximum columns and
+// rows. When no supported block type fits, the densest supported block type
+// is returned.
+//
+// When only ASCII glyphs are available, returns [ASCIIs]. Otherwise picks from
+// [Octants], [Braille] (substituting for [Octants]), [Sextants], [Quads],
+// [Halves] and [Solids].
+func (c Capabilities) Best(x, y int) Type {
+	// ordered by density
+	types := []Type{Octants, Braille, Sextants, Quads, Halves, Solids}
+	if c.ASCII {
+		types = []Type{ASCIIs}
+	}
+	var best Type
+	for _, typ := range types {
+		switch {
+		case !c.Supports(typ):
+			continue
+		case c.Fits(typ, x, y):
+			return typ
+		case best == 0:
+			best = typ
+		}
+	}
+	return best
+}
@@ -0,0 +1,182 @@
+package blocked
+
+import (
+	"image"
+)
+
+// Ditherer is the interface for dithering a grayscale image to a bitmap.
+//
+// Dither sets the bits of dst for the pixels in src, where dst and src have
+// the same dimensions, and the pixel at the minimum point of src corresponds to
+// the bit at the minimum point of dst. Pixels with a higher luminance are more
+// likely to be set.
+type Ditherer interface {
+	Dither(dst Bitmap, src *image.Gray)
+}
+
+// Error diffusion ditherers.
+var (
+	// FloydSteinberg is the Floyd-Steinberg error diffusion ditherer.
+	FloydSteinberg = ErrorDiffusion{
+		Matrix: [][]int{
+			{0, 0, 7},
+			{3, 5, 1},
+		},
+		Offset:  1,
+		Divisor: 16,
+	}
+	// Atkinson is the Atkinson error diffusion ditherer. Only 3/4 of the
+	// error is diffused, increasing the contrast.
+	Atkinson = ErrorDiffusion{
+		Matrix: [][]int{
+			{0, 0, 1, 1},
+			{1, 1, 1, 0},
+			{0, 1, 0, 0},
+		},
+		Offset:  1,
+		Divisor: 8,
+	}
+	// JarvisJudiceNinke is the Jarvis, Judice, and Ninke error diffusion
+	// ditherer.
+	JarvisJudiceNinke = ErrorDiffusion{
+		Matrix: [][]int{
+			{0, 0, 0, 7, 5},
+			{3, 5, 7, 5, 3},
+			{1, 3, 5, 3, 1},
+		},
+		Offset:  2,
+		Divisor: 48,
+	}
+	// Sierra is the (three row) Sierra error diffusion ditherer.
+	Sierra = ErrorDiffusion{
+		Matrix: [][]int{
+			{0, 0, 0, 5, 3},
+			{2, 4, 5, 4, 2},
+			{0, 2, 3, 2, 0},
+		},
+		Offset:  2,
+		Divisor: 32,
+	}
+)
+
+// Ordered ditherers.
+var (
+	// Bayer2x2 is the 2x2 Bayer ordered ditherer.
+	Bayer2x2 = bayer(2)
+	// Bayer4x4 is the 4x4 Bayer ordered ditherer.
+	Bayer4x4 = bayer(4)
+	// Bayer8x8 is the 8x8 Bayer ordered ditherer.
+	Bayer8x8 = bayer(8)
+	// BlueNoise is a 16x16 blue noise ordered ditherer, generated using
+	// Ulichney's void-and-cluster method, with a gaussian filter of sigma 1.5.
+	//
+	// See: https://doi.org/10.1117/12.152707
+	BlueNoise = Ordered{
+		Size: 16,
+		Map: []int{
+			75, 237, 35, 193, 220, 81, 226, 145, 1, 135, 211, 15, 199, 60, 253, 3,
+			182, 136, 92, 149, 65, 17, 161, 91, 249, 56, 77, 151, 39, 160, 90, 121,
+			45, 222, 9, 252, 181, 110, 206, 36, 172, 195, 122, 218, 103, 229, 26, 208,
+			64, 154, 112, 31, 53, 133, 241, 73, 113, 22, 232, 6, 177, 71, 141, 171,
+			239, 87, 203, 167, 224, 89, 12, 187, 155, 49, 93, 137, 54, 243, 114, 13,
+			186, 37, 127, 72, 183, 146, 43, 217, 130, 202, 251, 168, 192, 34, 213, 95,
+			143, 215, 4, 247, 24, 104, 233, 66, 100, 30, 70, 18, 82, 126, 159, 52,
+			109, 78, 162, 61, 117, 194, 158, 2, 176, 223, 144, 111, 236, 204, 7, 255,
+			27, 227, 190, 134, 212, 50, 84, 244, 120, 55, 197, 165, 44, 98, 68, 175,
+			118, 42, 96, 16, 235, 33, 138, 205, 21, 94, 231, 11, 132, 184, 221, 142,
+			240, 207, 148, 74, 166, 99, 179, 67, 156, 129, 38, 79, 248, 57, 23, 85,
+			0, 63, 178, 250, 115, 5, 228, 41, 254, 185, 209, 170, 147, 116, 201, 157,
+			189, 124, 28, 47, 214, 191, 131, 83, 107, 8, 59, 97, 14, 225, 40, 102,
+			80, 230, 139, 88, 153, 62, 20, 174, 150, 219, 123, 242, 196, 76, 173, 246,
+			19, 164, 210, 10, 245, 101, 200, 238, 69, 29, 163, 46, 140, 25, 128, 51,
+			198, 106, 58, 119, 169, 32, 125, 48, 188, 105, 234, 86, 180, 108, 216, 152,
+		},
+	}
+)
+
+// ErrorDiffusion is an error diffusion ditherer, that distributes each
+// pixel's quantization error to its unprocessed neighbors.
+type ErrorDiffusion struct {
+	// Matrix is the diffusion matrix, with the first row being the current
+	// row.
+	Matrix [][]int
+	// Offset is the column of the current pixel in the diffusion matrix.
+	Offset int
+	// Divisor is the divisor of the diffusion matrix's weights.
+	Divisor int
+	// Serpentine toggles alternating the scan direction of each row.
+	Serpentine bool
+}
+
+// Dither satisfies the [Ditherer] interface.
+func (d ErrorDiffusion) Dither(dst Bitmap, src *image.Gray) {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	errs := make([]int, w*h)
+	for y := range h {
+		rev := d.Serpentine && y%2 == 1
+		for i := range w {
+			x, dir := i, 1
+			if rev {
+				x, dir = w-1-i, -1
+			}
+			v := int(src.Pix[y*src.Stride+x]) + errs[y*w+x]
+			e := v
+			if 128 <= v {
+				e = v - 255
+				dst.Set(dst.Rect.Min.X+x, dst.Rect.Min.Y+y, true)
+			}
+			for j, row := range d.Matrix {
+				for k, n := range row {
+					dx, dy := dir*(k-d.Offset), y+j
+					if n == 0 || x+dx < 0 || w <= x+dx || h <= dy {
+						continue
+					}
+					errs[dy*w+x+dx] += e * n / d.Divisor
+				}
+			}
+		}
+	}
+}
+
+// Ordered is an ordered ditherer, that compares each pixel to the
+// corresponding value of a threshold map tiled over the image.
+type Ordered struct {
+	// Size is the width and height of the threshold map.
+	Size int
+	// Map is the threshold map, containing Size*Size values in [0, Size*Size).
+	Map []int
+}
+
+// Dither satisfies the [Ditherer] interface.
+func (d Ordered) Dither(dst Bitmap, src *image.Gray) {
+	n := d.Size * d.Size
+	for y := range src.Rect.Dy() {
+		for x := range src.Rect.Dx() {
+			m := d.Map[y%d.Size*d.Size+x%d.Size]
+			dst.Set(dst.Rect.Min.X+x, dst.Rect.Min.Y+y, (2*m+1)*256 <= 2*n*int(src.Pix[y*src.Stride+x]))
+		}
+	}
+}
+
+// bayer builds a size x size Bayer ordered ditherer, where size is a power
+// of 2.
+func bayer(size int) Ordered {
+	m := []int{0}
+	for n := 1; n < size; n *= 2 {
+		v := make([]int, 4*n*n)
+		for y := range n {
+			for x := range n {
+				i := 4 * m[y*n+x]
+				v[y*2*n+x] = i
+				v[y*2*n+x+n] = i + 2
+				v[(y+n)*2*n+x] = i + 3
+				v[(y+n)*2*n+x+n] = i + 1
+			}
+		}
+		m = v
+	}
+	return Ordered{
+		Size: size,
+		Map:  m,
+	}
+}
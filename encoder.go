@@ -0,0 +1,90 @@
+package blocked
+
+import (
+	"image"
+	"io"
+	"math"
+)
+
+// Encoder is a streaming block encoder, encoding a bitmap of unknown height
+// one row at a time.
+//
+// The encoder buffers a single band of [Type.Height] rows, writing a line of
+// blocks terminated by a newline to the underlying writer as soon as the band
+// is complete. The output is identical to [Bitmap.Encode] for a bitmap having
+// the same rows, except that the last line is also terminated by a newline.
+type Encoder struct {
+	w    io.Writer
+	syms map[uint8]rune
+	x, n int
+	band Bitmap
+	rows int
+	err  error
+}
+
+// NewEncoder creates a new streaming block encoder for the writer, encoding
+// rows of width pixels using the block type.
+//
+// When typ is [Auto], the block type is determined by the
+// [DefaultCapabilities] for a bitmap of unbounded height, ignoring the
+// maximum rows.
+func NewEncoder(w io.Writer, typ Type, width int) *Encoder {
+	if typ == Auto {
+		c := DefaultCapabilities
+		c.MaxRows = 0
+		typ = c.Best(width, math.MaxInt)
+	}
+	syms, x, y, n := typ.encoding()
+	e := &Encoder{
+		w:    w,
+		syms: syms,
+		x:    x,
+		n:    n,
+	}
+	if syms == nil || width < 0 {
+		e.err = ErrUnknownType
+		return e
+	}
+	e.band = NewImage(image.Rect(0, 0, width, y))
+	return e
+}
+
+// WriteRow writes a row of pixels to the encoder. Rows longer than the
+// encoder's width are truncated, and shorter rows are padded with unset
+// pixels.
+func (e *Encoder) WriteRow(row []bool) error {
+	if e.err != nil {
+		return e.err
+	}
+	for x := range min(len(row), e.band.Rect.Dx()) {
+		e.band.Set(x, e.rows, row[x])
+	}
+	if e.rows++; e.rows == e.band.Rect.Dy() {
+		return e.flush()
+	}
+	return nil
+}
+
+// Close flushes any partially buffered band to the underlying writer. Close
+// does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.err != nil || e.rows == 0 {
+		return e.err
+	}
+	return e.flush()
+}
+
+// flush writes the buffered band to the underlying writer, terminated by a
+// newline.
+func (e *Encoder) flush() error {
+	band := e.band.SubImage(image.Rect(0, 0, e.band.Rect.Dx(), e.rows))
+	if e.err = enc(e.w, band, e.x, e.band.Rect.Dy(), e.n, e.syms); e.err != nil {
+		return e.err
+	}
+	if _, e.err = e.w.Write(nl); e.err != nil {
+		return e.err
+	}
+	clear(e.band.Pix)
+	e.rows = 0
+	return nil
+}
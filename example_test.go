@@ -88,7 +88,7 @@ func Example() {
 	// |⠉⠀⠉⠉⠉⠀⠉⠈⠈⠈⠁⠈⠁⠁⠀⠈⠈⠁⠁⠈⠀⠉⠈⠈⠀⠁⠀⠈⠉⠈⠉⠉|
 	//
 	// 1f. Auto:
-	// ██  ██████  ██ █ █ ██  ██ █    █ ██ █  █  ██ █ █  █    ███ █████
+	// 🮂 🮂🮂🮂 🮂𜺫𜺫𜺫𜺨𜺫𜺨𜺨 𜺫𜺫𜺨𜺨𜺫 🮂𜺫𜺫 𜺨 𜺫🮂𜺫🮂🮂
 	//
 	// 2a. Solids:
 	// |█████  ████ █  █ █ █    █   █    █████ █  █ █ █   ███ ████ █ ███|
@@ -116,8 +116,8 @@ func Example() {
 	// |⠈⠋⠁⠈⠋⠂⠁⠑⠚⠑⠁⠒⠁⠁⠘⠙⠑⠃⠛⠓⠃⠉⠉⠁⠐⠀⠚⠚⠉⠃⠛⠈|
 	//
 	// 2f. Auto:
-	// 🬙🬥🬪🬷🬥🬮🬛🬷🬁🬑🬦🬞🬗🬏🬗🬋🬠🬒🬕🬠🬓🬄🬶🬪🬵🬕🬺🬂🬒🬜🬁🬙
-	// 🬊🬛🬃🬉🬚🬒🬅🬥🬶🬢🬆🬮🬄🬃🬦🬪🬣🬕🬺🬴▌🬌🬋🬃🬠🬂🬵🬶🬋🬓█🬈
+	// 𜷆𜵘𜴤𜶦𜴟𜷛𜶍▟𜴷𜴋𜷓𜵑𜵌𜴉𜴑𜵁𜵓𜶾𜵊𜷍𜵈𜴺𜴰𜴤𜶤𜷂𜴴𜴸𜴌𜴖𜶭𜵍
+	// 𜺫𜴂𜺨𜺫𜴂𜴀𜺨𜴄𜴈𜴄𜺨𜴆𜺨𜺨▝𜴅𜴄▘▀𜴇▘🮂🮂𜺨𜴃 𜴈𜴈🮂▘▀𜺫
 	//
 	// 3a. Solids:
 	// |█   █  ██  ██    █ ██ █   █ ██ █ █     ██  █ █ ██ █ █  ████   ██|
@@ -158,8 +158,7 @@ func Example() {
 	// |⡱⠑⢗⢃⢹⡹⠚⢇⢎⣺⢙⣚⢄⠋⢐⡐⠀⠷⢖⠠⣋⣍⢀⢨⠃⣚⢍⣡⢛⣢⢹⢖|
 	//
 	// 3f. Auto:
-	// 🬪🬢🬪▐🬀🬔🬟🬚🬘🬯🬛▌🬞🬀🬥🬤🬫🬃🬭▐🬌🬳🬻🬷🬺🬈🬌🬯🬬🬮 🬡
-	// 🬗🬕🬭🬫▐🬴🬇🬱🬥▐🬱🬦🬞🬡🬡▐🬲🬜🬉🬸🬉🬃🬦🬡🬗🬜🬄🬳🬕🬧🬡🬲
-	// 🬘🬏🬜🬝🬺🬻🬢🬕🬟🬧🬰🬢🬇🬴🬅🬄🬂🬒🬉 🬯🬶🬍🬦🬑🬤🬴🬘🬶 🬭🬅
-	// 🬘🬁🬥🬟▐🬘🬂🬣🬣🬸🬠🬰🬢🬀🬠🬑 🬎🬥🬇🬮🬱🬞🬦🬀🬰🬢🬵🬡🬶▐🬥
+	// 𜵞𜷏𜴤▐𜵱𜷁𜴙𜴔𜷅𜶞𜴕𜴍𜴘𜶬𜷒𜶔𜵟𜵴𜶜𜷕𜵽𜴭𜴵▟𜵮𜵹𜵂𜶞𜷚𜵢▂𜵔
+	// 𜶔▘𜷗𜷣𜷅𜷘𜴷𜵮𜴑𜶊𜴴𜴿𜵸𜵩𜵙▞𜴴𜴮𜶑𜴈𜴚𜶁𜷕𜵸𜴞𜵘𜵢𜶚𜶅▝𜴃𜵞
+	// 𜵚𜴄𜶍𜵵𜶘𜵜𜴈𜶅𜶆𜷙𜵻𜶹𜶀𜴂𜵸𜴽 𜴴𜶌𜴘𜶲𜶾𜺠𜶑▘𜶹𜶃𜷌𜵿𜷏𜶘𜶌
 }
@@ -0,0 +1,129 @@
+package blocked
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// DefaultDitherer is the default ditherer used when encoding grayscale
+// bitmaps.
+var DefaultDitherer Ditherer = FloydSteinberg
+
+// GrayBitmap is a multi-level grayscale bitmap image, with 2, 4, or 8 bits
+// per pixel.
+type GrayBitmap struct {
+	Pix    []uint8
+	Stride int
+	Rect   image.Rectangle
+	Depth  int
+}
+
+// NewGray creates a blank grayscale bitmap image with dimensions in rect and
+// the bit depth (2, 4, or 8).
+func NewGray(rect image.Rectangle, depth int) (GrayBitmap, error) {
+	switch depth {
+	case 2, 4, 8:
+	default:
+		return GrayBitmap{}, fmt.Errorf("invalid depth %d", depth)
+	}
+	x := rect.Dx()
+	return GrayBitmap{
+		Pix:    make([]uint8, (x*rect.Dy()*depth+7)/8),
+		Stride: x,
+		Rect:   rect,
+		Depth:  depth,
+	}, nil
+}
+
+// Max returns the maximum level for the bitmap's depth.
+func (img GrayBitmap) Max() uint8 {
+	return uint8(1<<img.Depth - 1)
+}
+
+// PixOffset returns the offset of the first bit in Pix for x, y.
+func (img GrayBitmap) PixOffset(x, y int) int {
+	return ((y-img.Rect.Min.Y)*img.Stride + (x - img.Rect.Min.X)) * img.Depth
+}
+
+// Set sets the level at x, y, clamped to [GrayBitmap.Max]. Does nothing when
+// x, y is not in the bitmap's bounds.
+func (img GrayBitmap) Set(x, y int, v uint8) {
+	if !image.Pt(x, y).In(img.Rect) {
+		return
+	}
+	i, m := img.PixOffset(x, y), img.Max()
+	img.Pix[i/8] = img.Pix[i/8]&^(m<<(i%8)) | min(v, m)<<(i%8)
+}
+
+// Get returns the level at x, y. Returns 0 when x, y is not in the bitmap's
+// bounds.
+func (img GrayBitmap) Get(x, y int) uint8 {
+	if !image.Pt(x, y).In(img.Rect) {
+		return 0
+	}
+	i := img.PixOffset(x, y)
+	return img.Pix[i/8] >> (i % 8) & img.Max()
+}
+
+// ColorModel satisfies the [image.Image] interface.
+func (img GrayBitmap) ColorModel() color.Model {
+	return color.GrayModel
+}
+
+// Bounds satisfies the [image.Image] interface. Unlike [Bitmap], grayscale
+// bitmaps are not scaled when used as an [image.Image].
+func (img GrayBitmap) Bounds() image.Rectangle {
+	return img.Rect
+}
+
+// At satisfies the [image.Image] interface.
+func (img GrayBitmap) At(x, y int) color.Color {
+	return color.Gray{uint8(int(img.Get(x, y)) * 255 / int(img.Max()))}
+}
+
+// Gray returns the grayscale bitmap as a 8-bit grayscale image, with bounds
+// starting at 0, 0.
+func (img GrayBitmap) Gray() *image.Gray {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	gray, m := image.NewGray(image.Rect(0, 0, w, h)), int(img.Max())
+	for y := range h {
+		for x := range w {
+			gray.Pix[gray.PixOffset(x, y)] = uint8(int(img.Get(img.Rect.Min.X+x, img.Rect.Min.Y+y)) * 255 / m)
+		}
+	}
+	return gray
+}
+
+// Dither dithers the grayscale bitmap to a bitmap using the ditherer, or
+// [DefaultDitherer] when nil.
+func (img GrayBitmap) Dither(d Ditherer) Bitmap {
+	if d == nil {
+		d = DefaultDitherer
+	}
+	dst := NewImage(img.Rect)
+	d.Dither(dst, img.Gray())
+	return dst
+}
+
+// Encode encodes the grayscale bitmap to the writer using the block type. The
+// [Shades] block type encodes the levels using [EncodeShades], while all other
+// block types encode the bitmap dithered using [DefaultDitherer].
+func (img GrayBitmap) Encode(w io.Writer, typ Type) error {
+	if typ == Shades {
+		return EncodeShades(w, img)
+	}
+	return img.Dither(nil).Encode(w, typ)
+}
+
+// Format satisfies the [fmt.Formatter] interface.
+func (img GrayBitmap) Format(f fmt.State, verb rune) {
+	if Type(verb) != Shades {
+		img.Dither(nil).Format(f, verb)
+		return
+	}
+	if err := EncodeShades(f, img); err != nil {
+		fmt.Fprintf(f, "%%!%c(ERROR: %v)", verb, err)
+	}
+}
@@ -0,0 +1,190 @@
+package blocked
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"unicode/utf8"
+)
+
+// FromImage creates a bitmap from the image, setting the bits for pixels
+// having a luminance at or above the threshold level. By default, the level
+// is the midpoint (128), and pixels having an alpha below the midpoint are
+// unset.
+//
+// Use [WithThreshold], [WithAlphaThreshold] and [WithInvert] to change how
+// pixels are thresholded. To create a bitmap using only the alpha of the
+// image, use [WithThreshold] with [Level](0). Use [WithDitherer] to dither the
+// image instead of thresholding its luminance.
+func FromImage(src image.Image, opts ...Option) Bitmap {
+	o := newOptions(opts...)
+	gray, alpha := grayscale(src)
+	img := NewImage(gray.Rect)
+	if o.ditherer != nil {
+		if o.invert {
+			for i, v := range gray.Pix {
+				gray.Pix[i] = 255 - v
+			}
+		}
+		o.ditherer.Dither(img, gray)
+		for y := range gray.Rect.Dy() {
+			for x := range gray.Rect.Dx() {
+				if alpha.Pix[alpha.PixOffset(x, y)] < o.alpha {
+					img.Set(x, y, false)
+				}
+			}
+		}
+		return img
+	}
+	level := o.threshold.Threshold(gray)
+	for y := range gray.Rect.Dy() {
+		for x := range gray.Rect.Dx() {
+			i := gray.PixOffset(x, y)
+			img.Set(x, y, o.alpha <= alpha.Pix[i] && (level <= gray.Pix[i]) != o.invert)
+		}
+	}
+	return img
+}
+
+// EncodeShades encodes the image to the writer using the [Shades] block type,
+// mapping the luminance of each pixel in the image to the closest shade in
+// [ShadesRunes]. Transparent pixels are treated as black.
+func EncodeShades(w io.Writer, src image.Image) error {
+	gray, alpha := grayscale(src)
+	syms := Shades.runeMap()
+	n := len(syms) - 1
+	var buf []byte
+	v := make([]byte, 4)
+	for y := range gray.Rect.Dy() {
+		buf = buf[:0]
+		if y != 0 {
+			buf = append(buf, '\n')
+		}
+		for x := range gray.Rect.Dx() {
+			i := gray.PixOffset(x, y)
+			l := int(gray.Pix[i]) * int(alpha.Pix[i]) / 255
+			buf = append(buf, v[:utf8.EncodeRune(v, syms[uint8((l*n+127)/255)])]...)
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Canvas wraps a bitmap as a [draw.Image] at the bitmap's native (unscaled)
+// resolution, allowing [draw.Draw] and other rasterizers to draw directly to
+// the bitmap.
+type Canvas struct {
+	Bitmap
+	// Threshold is the alpha level at or above which bits are set. When 0,
+	// the midpoint (0x8000) is used.
+	Threshold uint16
+}
+
+// Canvas returns a [Canvas] for the bitmap, sharing the bitmap's pixels.
+func (img Bitmap) Canvas() Canvas {
+	return Canvas{
+		Bitmap: img,
+	}
+}
+
+// Bounds satisfies the [image.Image] interface.
+func (c Canvas) Bounds() image.Rectangle {
+	return c.Rect
+}
+
+// At satisfies the [image.Image] interface.
+func (c Canvas) At(x, y int) color.Color {
+	if c.Get(x, y) {
+		return c.Opaque
+	}
+	return c.Transparent
+}
+
+// Set satisfies the [draw.Image] interface, setting the bit at x, y when the
+// color's alpha is at or above the threshold.
+func (c Canvas) Set(x, y int, clr color.Color) {
+	threshold := c.Threshold
+	if threshold == 0 {
+		threshold = 0x8000
+	}
+	_, _, _, a := clr.RGBA()
+	c.Bitmap.Set(x, y, uint32(threshold) <= a)
+}
+
+// Thresholder is the interface for determining the luminance threshold level
+// of a grayscale image.
+type Thresholder interface {
+	Threshold(*image.Gray) uint8
+}
+
+// ThresholdFunc is a [Thresholder] func.
+type ThresholdFunc func(*image.Gray) uint8
+
+// Threshold satisfies the [Thresholder] interface.
+func (f ThresholdFunc) Threshold(img *image.Gray) uint8 {
+	return f(img)
+}
+
+// Level is a fixed luminance threshold level.
+type Level uint8
+
+// Threshold satisfies the [Thresholder] interface.
+func (level Level) Threshold(*image.Gray) uint8 {
+	return uint8(level)
+}
+
+// Otsu is a [Thresholder] that determines the threshold level using Otsu's
+// method, maximizing the between-class variance of the image's luminance
+// histogram.
+//
+// See: https://en.wikipedia.org/wiki/Otsu%27s_method
+var Otsu Thresholder = ThresholdFunc(otsu)
+
+// otsu determines the threshold level for the image using Otsu's method.
+func otsu(img *image.Gray) uint8 {
+	var hist [256]int
+	for y := range img.Rect.Dy() {
+		i := y * img.Stride
+		for _, v := range img.Pix[i : i+img.Rect.Dx()] {
+			hist[v]++
+		}
+	}
+	total, sum := 0, 0
+	for i, n := range hist {
+		total, sum = total+n, sum+i*n
+	}
+	var level uint8
+	var best float64
+	n0, sum0 := 0, 0
+	for i, n := range hist[:255] {
+		n0, sum0 = n0+n, sum0+i*n
+		n1 := total - n0
+		if n0 == 0 || n1 == 0 {
+			continue
+		}
+		m0, m1 := float64(sum0)/float64(n0), float64(sum-sum0)/float64(n1)
+		if v := float64(n0) * float64(n1) * (m0 - m1) * (m0 - m1); best < v {
+			best, level = v, uint8(i+1)
+		}
+	}
+	return level
+}
+
+// grayscale converts the image to its (non-premultiplied) luminance and alpha
+// planes, with bounds starting at 0, 0.
+func grayscale(src image.Image) (*image.Gray, *image.Alpha) {
+	b := src.Bounds()
+	r := image.Rect(0, 0, b.Dx(), b.Dy())
+	gray, alpha := image.NewGray(r), image.NewAlpha(r)
+	for y := range r.Dy() {
+		for x := range r.Dx() {
+			c := color.NRGBAModel.Convert(src.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			i := gray.PixOffset(x, y)
+			gray.Pix[i] = uint8((19595*uint32(c.R) + 38470*uint32(c.G) + 7471*uint32(c.B) + 1<<15) >> 16)
+			alpha.Pix[i] = c.A
+		}
+	}
+	return gray, alpha
+}
@@ -0,0 +1,174 @@
+package blocked
+
+import (
+	"image"
+	"math/bits"
+)
+
+// Op is a raster operation.
+type Op int
+
+// Raster operations.
+const (
+	// OpCopy copies the source bits to the destination.
+	OpCopy Op = iota
+	// OpAnd is the bitwise AND of the destination and source bits.
+	OpAnd
+	// OpOr is the bitwise OR of the destination and source bits.
+	OpOr
+	// OpXor is the bitwise XOR of the destination and source bits.
+	OpXor
+	// OpAndNot is the bitwise AND NOT (bit clear) of the destination and
+	// source bits.
+	OpAndNot
+	// opNot is the bitwise NOT of the destination bits.
+	opNot Op = -1
+)
+
+// And returns the bitwise AND of a and b, with the bounds of a. Points in a
+// outside the bounds of b are treated as unset in b.
+func And(a, b Bitmap) Bitmap {
+	return combine(a, b, OpAnd)
+}
+
+// Or returns the bitwise OR of a and b, with the bounds of a. Points in a
+// outside the bounds of b are treated as unset in b.
+func Or(a, b Bitmap) Bitmap {
+	return combine(a, b, OpOr)
+}
+
+// Xor returns the bitwise XOR of a and b, with the bounds of a. Points in a
+// outside the bounds of b are treated as unset in b.
+func Xor(a, b Bitmap) Bitmap {
+	return combine(a, b, OpXor)
+}
+
+// AndNot returns the bitwise AND NOT (bit clear) of a and b, with the bounds
+// of a. Points in a outside the bounds of b are treated as unset in b.
+func AndNot(a, b Bitmap) Bitmap {
+	return combine(a, b, OpAndNot)
+}
+
+// Not returns the bitwise NOT of the bitmap.
+func Not(img Bitmap) Bitmap {
+	dst := img.Clone()
+	Blit(dst, dst, dst.Rect.Min, opNot)
+	return dst
+}
+
+// Blit composes src onto dst using the raster operation, with the minimum
+// point of src aligned with the point at in dst. Only the bits of dst that
+// overlap src are modified. Src and dst may share pixels, such as a bitmap and
+// its [Bitmap.SubImage].
+func Blit(dst, src Bitmap, at image.Point, op Op) {
+	r := image.Rectangle{at, at.Add(src.Rect.Size())}.Intersect(dst.Rect)
+	if r.Empty() {
+		return
+	}
+	sp := r.Min.Sub(at).Add(src.Rect.Min)
+	if op != opNot && aliased(dst.Pix, src.Pix) {
+		// copy overlapping source bits before modifying dst
+		src = src.SubImage(image.Rectangle{sp, sp.Add(r.Size())}).Clone()
+		sp = src.Rect.Min
+	}
+	if dst.Order != src.Order {
+		// bit at a time
+		for y := range r.Dy() {
+			for x := range r.Dx() {
+				d, s := dst.Get(r.Min.X+x, r.Min.Y+y), src.Get(sp.X+x, sp.Y+y)
+				dst.Set(r.Min.X+x, r.Min.Y+y, bitOp(d, s, op))
+			}
+		}
+		return
+	}
+	msb := dst.Order == MSBFirst
+	for y := range r.Dy() {
+		rowOp(dst.Pix, dst.PixOffset(r.Min.X, r.Min.Y+y), src.Pix, src.PixOffset(sp.X, sp.Y+y), r.Dx(), op, msb)
+	}
+}
+
+// combine returns the raster operation of a and b, with the bounds of a.
+func combine(a, b Bitmap, op Op) Bitmap {
+	// b, clipped to a
+	m := a.blank(a.Rect)
+	Blit(m, b, b.Rect.Min, OpCopy)
+	dst := a.Clone()
+	Blit(dst, m, m.Rect.Min, op)
+	return dst
+}
+
+// aliased returns true when a and b share the same underlying array.
+func aliased(a, b []byte) bool {
+	return cap(a) != 0 && cap(b) != 0 && &a[:cap(a)][cap(a)-1] == &b[:cap(b)][cap(b)-1]
+}
+
+// bitOp returns the raster operation of the destination and source bits.
+func bitOp(d, s bool, op Op) bool {
+	switch op {
+	case OpCopy:
+		return s
+	case OpAnd:
+		return d && s
+	case OpOr:
+		return d || s
+	case OpXor:
+		return d != s
+	case OpAndNot:
+		return d && !s
+	case opNot:
+		return !d
+	}
+	return d
+}
+
+// rowOp applies the raster operation to the n bits of dst starting at bit
+// offset di, using the n bits of src starting at bit offset si. Bits are
+// processed a destination byte at a time, in [MSBFirst] order when msb is
+// true.
+func rowOp(dst []byte, di int, src []byte, si, n int, op Op, msb bool) {
+	for n > 0 {
+		o := di % 8
+		m := min(8-o, n)
+		mask := uint8(1<<m-1) << o
+		d, s := dst[di/8], readBits(src, si, m, msb)<<o
+		if msb {
+			mask, s = bits.Reverse8(mask), bits.Reverse8(s)
+		}
+		switch op {
+		case OpCopy:
+			d = d&^mask | s
+		case OpAnd:
+			d &= s | ^mask
+		case OpOr:
+			d |= s
+		case OpXor:
+			d ^= s
+		case OpAndNot:
+			d &^= s
+		case opNot:
+			d ^= mask
+		}
+		dst[di/8] = d
+		di, si, n = di+m, si+m, n-m
+	}
+}
+
+// readBits returns the n (<= 8) bits of buf starting at bit offset i, with
+// the first bit as bit 0. Bits in buf are in [MSBFirst] order when msb is
+// true.
+func readBits(buf []byte, i, n int, msb bool) uint8 {
+	o := i % 8
+	var v uint8
+	if msb {
+		v = buf[i/8] << o
+		if 8 < o+n {
+			v |= buf[i/8+1] >> (8 - o)
+		}
+		return bits.Reverse8(v) & uint8(1<<n-1)
+	}
+	v = buf[i/8] >> o
+	if 8 < o+n {
+		v |= buf[i/8+1] << (8 - o)
+	}
+	return v & uint8(1<<n-1)
+}
@@ -0,0 +1,63 @@
+package blocked
+
+import (
+	"fmt"
+	"image"
+	"io"
+)
+
+// NewPaged creates a new bitmap with width w, height h from the page
+// oriented data used by monochrome OLED and LCD display controllers (such as
+// the SSD1306, SH1106 and ST7565), where each byte holds 8 vertically adjacent
+// pixels of a page (band) of 8 rows, with the byte for column x of page p
+// being data[p*w+x].
+//
+// By default, bit 0 of each byte is the top pixel. Use [WithBitOrder] with
+// [MSBFirst] when bit 7 is the top pixel. Returns [io.ErrShortBuffer] when
+// data is too short.
+func NewPaged(data []byte, w, h int, opts ...Option) (Bitmap, error) {
+	switch {
+	case w < 0 || h < 0:
+		return Bitmap{}, fmt.Errorf("invalid size %dx%d", w, h)
+	case len(data) < w*((h+7)/8):
+		return Bitmap{}, io.ErrShortBuffer
+	}
+	o := newOptions(opts...)
+	img := NewImage(image.Rect(0, 0, w, h))
+	for y := range h {
+		m := pageMask(y, o.order)
+		for x := range w {
+			if data[y/8*w+x]&m != 0 {
+				img.Set(x, y, true)
+			}
+		}
+	}
+	return img, nil
+}
+
+// Paged returns the bitmap in the page oriented layout read by [NewPaged],
+// padding the last page with unset pixels. By default, bit 0 of each byte is
+// the top pixel. Use [WithBitOrder] with [MSBFirst] when bit 7 is the top
+// pixel.
+func (img Bitmap) Paged(opts ...Option) []byte {
+	o := newOptions(opts...)
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	data := make([]byte, w*((h+7)/8))
+	for y := range h {
+		m := pageMask(y, o.order)
+		for x := range w {
+			if img.Get(img.Rect.Min.X+x, img.Rect.Min.Y+y) {
+				data[y/8*w+x] |= m
+			}
+		}
+	}
+	return data
+}
+
+// pageMask returns the mask for row y in its page.
+func pageMask(y int, order BitOrder) uint8 {
+	if order == MSBFirst {
+		return 0x80 >> (y % 8)
+	}
+	return 1 << (y % 8)
+}
@@ -0,0 +1,341 @@
+package blocked
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+func init() {
+	image.RegisterFormat("pbm", "P1", decodePBM, DecodePBMConfig)
+	image.RegisterFormat("pbm", "P4", decodePBM, DecodePBMConfig)
+	image.RegisterFormat("pgm", "P2", decodePGM, DecodePGMConfig)
+	image.RegisterFormat("pgm", "P5", decodePGM, DecodePGMConfig)
+}
+
+// ErrInvalidNetpbm is the invalid Netpbm image error.
+var ErrInvalidNetpbm = errors.New("invalid netpbm image")
+
+// maxNetpbmPixels is the maximum number of pixels of a decoded Netpbm image.
+const maxNetpbmPixels = 1 << 26
+
+// pbmPalette is the palette of Netpbm PBM images decoded by [image.Decode],
+// where set pixels are black.
+var pbmPalette = color.Palette{color.White, color.Black}
+
+// DecodePBM decodes a plain (P1) or raw (P4) Netpbm PBM image from the
+// reader, where set pixels are 1 (black) in the image. The decoded bitmap's
+// bounds start at 0, 0, and has a scale of 1.
+//
+// Only the first image is decoded from the reader. Returns
+// [ErrInvalidNetpbm] for images larger than 2^26 pixels.
+func DecodePBM(r io.Reader) (Bitmap, error) {
+	br := bufio.NewReader(r)
+	magic, v, err := readHeader(br, 2)
+	if err != nil {
+		return Bitmap{}, err
+	}
+	w, h := v[0], v[1]
+	if magic != "P1" && magic != "P4" {
+		return Bitmap{}, ErrInvalidNetpbm
+	}
+	// read rows incrementally, so truncated data does not allocate the full
+	// image
+	stride := AlignStride(w, 8)
+	row := make([]byte, stride/8)
+	var pix []byte
+	for range h {
+		if magic == "P4" {
+			if _, err := io.ReadFull(br, row); err != nil {
+				return Bitmap{}, err
+			}
+		} else {
+			clear(row)
+			for x := range w {
+				c, err := readNonSpace(br)
+				switch {
+				case err != nil:
+					return Bitmap{}, err
+				case c != '0' && c != '1':
+					return Bitmap{}, ErrInvalidNetpbm
+				case c == '1':
+					row[x/8] |= 0x80 >> (x % 8)
+				}
+			}
+		}
+		pix = append(pix, row...)
+	}
+	img, err := Wrap(pix, w, h, stride, WithBitOrder(MSBFirst))
+	if err != nil {
+		return Bitmap{}, err
+	}
+	img.ScaleWidth, img.ScaleHeight = 1, 1
+	return img, nil
+}
+
+// DecodePBMConfig returns the color model and dimensions of a Netpbm PBM
+// image without decoding the entire image. The color model is the palette of
+// images decoded by [image.Decode], where set pixels are black and unset
+// pixels are white.
+func DecodePBMConfig(r io.Reader) (image.Config, error) {
+	magic, v, err := readHeader(bufio.NewReader(r), 2)
+	switch {
+	case err != nil:
+		return image.Config{}, err
+	case magic != "P1" && magic != "P4":
+		return image.Config{}, ErrInvalidNetpbm
+	}
+	return image.Config{
+		ColorModel: pbmPalette,
+		Width:      v[0],
+		Height:     v[1],
+	}, nil
+}
+
+// EncodePBM encodes the bitmap to the writer as a plain (P1) or raw (P4)
+// Netpbm PBM image, where set pixels are 1 (black) in the image.
+func EncodePBM(w io.Writer, img Bitmap, plain bool) error {
+	bw := bufio.NewWriter(w)
+	x, y, p := img.Rect.Dx(), img.Rect.Dy(), img.Rect.Min
+	magic := "P4"
+	if plain {
+		magic = "P1"
+	}
+	fmt.Fprintf(bw, "%s\n%d %d\n", magic, x, y)
+	row := make([]byte, (x+7)/8)
+	for j := range y {
+		if plain {
+			for i := range x {
+				if i != 0 && i%70 == 0 {
+					bw.WriteByte('\n')
+				}
+				c := byte('0')
+				if img.Get(p.X+i, p.Y+j) {
+					c = '1'
+				}
+				bw.WriteByte(c)
+			}
+			bw.WriteByte('\n')
+			continue
+		}
+		clear(row)
+		for i := range x {
+			if img.Get(p.X+i, p.Y+j) {
+				row[i/8] |= 0x80 >> (i % 8)
+			}
+		}
+		bw.Write(row)
+	}
+	return bw.Flush()
+}
+
+// DecodePGM decodes a plain (P2) or raw (P5) Netpbm PGM image from the reader
+// to a 8-bit grayscale bitmap, scaling gray levels to 0-255. The decoded
+// bitmap's bounds start at 0, 0.
+//
+// Only the first image is decoded from the reader. Returns
+// [ErrInvalidNetpbm] for images larger than 2^26 pixels.
+func DecodePGM(r io.Reader) (GrayBitmap, error) {
+	br := bufio.NewReader(r)
+	magic, v, err := readHeader(br, 3)
+	if err != nil {
+		return GrayBitmap{}, err
+	}
+	w, h, maxval := v[0], v[1], v[2]
+	if maxval < 1 || 65535 < maxval || magic != "P2" && magic != "P5" {
+		return GrayBitmap{}, ErrInvalidNetpbm
+	}
+	n := 1
+	if 255 < maxval {
+		n = 2
+	}
+	// append pixels as read, so truncated data does not allocate the full
+	// image
+	buf := make([]byte, n)
+	var pix []uint8
+	for range w * h {
+		var v int
+		if magic == "P2" {
+			if v, err = readInt(br); err != nil {
+				return GrayBitmap{}, err
+			}
+		} else {
+			if _, err := io.ReadFull(br, buf); err != nil {
+				return GrayBitmap{}, err
+			}
+			v = int(buf[0])
+			if n == 2 {
+				v = v<<8 | int(buf[1])
+			}
+		}
+		if maxval < v {
+			return GrayBitmap{}, ErrInvalidNetpbm
+		}
+		pix = append(pix, uint8((v*255+maxval/2)/maxval))
+	}
+	return GrayBitmap{
+		Pix:    pix,
+		Stride: w,
+		Rect:   image.Rect(0, 0, w, h),
+		Depth:  8,
+	}, nil
+}
+
+// DecodePGMConfig returns the color model and dimensions of a Netpbm PGM
+// image without decoding the entire image.
+func DecodePGMConfig(r io.Reader) (image.Config, error) {
+	magic, v, err := readHeader(bufio.NewReader(r), 3)
+	switch {
+	case err != nil:
+		return image.Config{}, err
+	case magic != "P2" && magic != "P5":
+		return image.Config{}, ErrInvalidNetpbm
+	}
+	return image.Config{
+		ColorModel: color.GrayModel,
+		Width:      v[0],
+		Height:     v[1],
+	}, nil
+}
+
+// EncodePGM encodes the luminance of the image to the writer as a plain (P2)
+// or raw (P5) 8-bit Netpbm PGM image.
+func EncodePGM(w io.Writer, src image.Image, plain bool) error {
+	gray, _ := grayscale(src)
+	bw := bufio.NewWriter(w)
+	x, y := gray.Rect.Dx(), gray.Rect.Dy()
+	magic := "P5"
+	if plain {
+		magic = "P2"
+	}
+	fmt.Fprintf(bw, "%s\n%d %d\n255\n", magic, x, y)
+	for j := range y {
+		row := gray.Pix[j*gray.Stride : j*gray.Stride+x]
+		if !plain {
+			bw.Write(row)
+			continue
+		}
+		// lines are at most 70 characters
+		for i, v := range row {
+			if i != 0 {
+				if i%17 == 0 {
+					bw.WriteByte('\n')
+				} else {
+					bw.WriteByte(' ')
+				}
+			}
+			bw.WriteString(strconv.Itoa(int(v)))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// decodePBM decodes a Netpbm PBM image from the reader to a paletted image,
+// where set pixels are black and unset pixels are white.
+func decodePBM(r io.Reader) (image.Image, error) {
+	img, err := DecodePBM(r)
+	if err != nil {
+		return nil, err
+	}
+	p := image.NewPaletted(img.Rect, pbmPalette)
+	for y := range img.Rect.Dy() {
+		for x := range img.Rect.Dx() {
+			if img.Get(x, y) {
+				p.Pix[y*p.Stride+x] = 1
+			}
+		}
+	}
+	return p, nil
+}
+
+// decodePGM decodes a Netpbm PGM image from the reader.
+func decodePGM(r io.Reader) (image.Image, error) {
+	img, err := DecodePGM(r)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// readHeader reads a Netpbm header with n integer fields from the reader,
+// returning the magic number and fields. The first two fields are the width
+// and height, which must not exceed [maxNetpbmPixels] pixels.
+func readHeader(br *bufio.Reader, n int) (string, []int, error) {
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return "", nil, err
+	}
+	v := make([]int, n)
+	for i := range v {
+		var err error
+		if v[i], err = readInt(br); err != nil {
+			return "", nil, err
+		}
+	}
+	// single whitespace before raster
+	switch c, err := br.ReadByte(); {
+	case err != nil:
+		return "", nil, err
+	case !isSpace(c), maxNetpbmPixels < v[0]*v[1]:
+		return "", nil, ErrInvalidNetpbm
+	}
+	return string(magic), v, nil
+}
+
+// readInt reads a non-negative decimal integer from the reader, skipping
+// leading whitespace and comments.
+func readInt(br *bufio.Reader) (int, error) {
+	c, err := readNonSpace(br)
+	if err != nil {
+		return 0, err
+	}
+	if c < '0' || '9' < c {
+		return 0, ErrInvalidNetpbm
+	}
+	v := 0
+	for ; '0' <= c && c <= '9'; c, err = br.ReadByte() {
+		if v = v*10 + int(c-'0'); 1<<24 < v {
+			return 0, ErrInvalidNetpbm
+		}
+	}
+	switch {
+	case errors.Is(err, io.EOF):
+	case err != nil:
+		return 0, err
+	default:
+		br.UnreadByte()
+	}
+	return v, nil
+}
+
+// readNonSpace reads the next byte from the reader, skipping whitespace and
+// comments.
+func readNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		switch {
+		case err != nil:
+			return 0, err
+		case c == '#':
+			if _, err := br.ReadBytes('\n'); err != nil {
+				return 0, err
+			}
+		case !isSpace(c):
+			return c, nil
+		}
+	}
+}
+
+// isSpace returns true when c is Netpbm whitespace.
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
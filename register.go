@@ -0,0 +1,99 @@
+package blocked
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"unicode"
+)
+
+// Registration errors.
+var (
+	// ErrInvalidType is the invalid block type error.
+	ErrInvalidType = errors.New("invalid block type")
+	// ErrTypeExists is the block type exists error.
+	ErrTypeExists = errors.New("block type exists")
+)
+
+// Register registers a custom block type for the verb, made of w x h blocks
+// using the runes, where the rune at index i is the block having the pixel at
+// dx, dy set when bit dy*w+dx of i is set.
+//
+// When w is 0, the block type is double wide like [Emojis], where each block
+// is 1 pixel wide and its rune is written once and displayed as 2 columns, and
+// the rune at index i is the block having the pixel at dy set when bit dy of i
+// is set.
+//
+// The verb must be a letter not used by another block type (or by [Auto] and
+// 's') or handled by the fmt package ('T', 'p' and 'w'), the block must have
+// at most 8 pixels, and there must be exactly 2^pixels unique runes. Returns
+// [ErrTypeExists] when the verb is in use, or [ErrInvalidType] otherwise.
+//
+// Registered block types are returned by [Types] (after the built-in block
+// types, in the order registered), and can be used with [Bitmap.Encode],
+// [Bitmap.Format], [Decode] and [Sniff].
+func Register(verb rune, name string, w, h int, runes []rune) error {
+	typ, n := Type(verb), w*h
+	if w == 0 {
+		// double wide
+		n = h
+	}
+	switch {
+	case !unicode.IsLetter(verb) || name == "",
+		verb == 'T' || verb == 'p' || verb == 'w', // handled by fmt
+		w < 0 || h < 1 || 8 < w || 8 < h || 8 < n,
+		len(runes) != 1<<n:
+		return ErrInvalidType
+	}
+	seen := make(map[rune]bool, len(runes))
+	for _, r := range runes {
+		if seen[r] || r == '\n' || r == '\r' {
+			return ErrInvalidType
+		}
+		seen[r] = true
+	}
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	if _, ok := registered[typ]; ok || typ == Auto || verb == 's' || typ.builtin() {
+		return ErrTypeExists
+	}
+	registered[typ] = custom{
+		name:  name,
+		w:     w,
+		h:     h,
+		runes: slices.Clone(runes),
+	}
+	customOrder = append(customOrder, typ)
+	return nil
+}
+
+// custom is a registered block type.
+type custom struct {
+	name  string
+	w, h  int
+	runes []rune
+}
+
+// lookup returns the registered block type.
+func lookup(typ Type) (custom, bool) {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	c, ok := registered[typ]
+	return c, ok
+}
+
+// customTypes returns the registered block types.
+func customTypes() []Type {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return slices.Clone(customOrder)
+}
+
+var (
+	// registered are the registered block types.
+	registered = make(map[Type]custom)
+	// customOrder is the registered block types, in the order registered.
+	customOrder []Type
+	// registeredMu is the registered mutex.
+	registeredMu sync.RWMutex
+)
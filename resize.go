@@ -0,0 +1,134 @@
+package blocked
+
+import (
+	"image"
+	"io"
+	"math"
+)
+
+// DefaultResample is the default resampling method used by [Bitmap.Fit].
+var DefaultResample = Majority
+
+// Resample is a bitmap resampling method.
+type Resample int
+
+// Resampling methods.
+const (
+	// Nearest is nearest-neighbor resampling, using the source pixel at the
+	// center of each destination pixel.
+	Nearest Resample = iota
+	// Majority is majority vote (box filter) resampling, setting each
+	// destination pixel when at least half of the source pixels it covers are
+	// set.
+	Majority
+	// Any is OR-downsampling, setting each destination pixel when any of the
+	// source pixels it covers are set. Preserves thin lines when shrinking.
+	Any
+)
+
+// String satisfies the [fmt.Stringer] interface.
+func (method Resample) String() string {
+	switch method {
+	case Nearest:
+		return "Nearest"
+	case Majority:
+		return "Majority"
+	case Any:
+		return "Any"
+	}
+	return ""
+}
+
+// Resize returns a copy of the bitmap resized to w x h pixels using the
+// resampling method. The returned bitmap's bounds start at 0, 0.
+func (img Bitmap) Resize(w, h int, method Resample) Bitmap {
+	dst := img.blank(image.Rect(0, 0, max(w, 0), max(h, 0)))
+	sw, sh, p := img.Rect.Dx(), img.Rect.Dy(), img.Rect.Min
+	if sw == 0 || sh == 0 {
+		return dst
+	}
+	for y := range h {
+		y0, y1 := span(y, h, sh)
+		for x := range w {
+			x0, x1 := span(x, w, sw)
+			var b bool
+			switch method {
+			case Nearest:
+				b = img.Get(p.X+(2*x+1)*sw/(2*w), p.Y+(2*y+1)*sh/(2*h))
+			case Majority:
+				n := 0
+				for i := y0; i < y1; i++ {
+					for j := x0; j < x1; j++ {
+						if img.Get(p.X+j, p.Y+i) {
+							n++
+						}
+					}
+				}
+				b = (x1-x0)*(y1-y0) <= 2*n
+			case Any:
+				for i := y0; i < y1 && !b; i++ {
+					for j := x0; j < x1 && !b; j++ {
+						b = img.Get(p.X+j, p.Y+i)
+					}
+				}
+			}
+			dst.Set(x, y, b)
+		}
+	}
+	return dst
+}
+
+// Fit returns the bitmap resized using [DefaultResample] to fit within cols x
+// rows cells of the block type, preserving the bitmap's aspect ratio. The
+// bitmap is returned unchanged when it already fits.
+func (img Bitmap) Fit(cols, rows int, typ Type) Bitmap {
+	w, h := cols*typ.Width(), rows*typ.Height()
+	if typ.Width() == 0 {
+		// double wide
+		w = cols / 2
+	}
+	sw, sh := img.Rect.Dx(), img.Rect.Dy()
+	switch {
+	case sw <= w && sh <= h:
+		return img
+	case sw*h <= sh*w:
+		w = max(1, (sw*h+sh/2)/sh)
+	default:
+		h = max(1, (sh*w+sw/2)/sw)
+	}
+	return img.Resize(w, h, DefaultResample)
+}
+
+// EncodeAspect encodes the bitmap to the writer using the block type, first
+// stretching the bitmap using [Nearest] resampling so that it is displayed
+// with the correct aspect ratio in terminal cells with the cell aspect ratio
+// (height / width). See [Type.Aspect].
+//
+// When cell is 0, [DefaultCellAspect] is used. When typ is [Auto], the block
+// type is determined by [Bitmap.Best].
+func (img Bitmap) EncodeAspect(w io.Writer, typ Type, cell float64) error {
+	if typ == Auto {
+		typ = img.Best()
+	}
+	a := typ.Aspect(cell)
+	if a <= 0 {
+		return ErrUnknownType
+	}
+	x, y := img.Rect.Dx(), img.Rect.Dy()
+	switch {
+	case a > 1:
+		x = int(math.Round(float64(x) * a))
+	case a < 1:
+		y = int(math.Round(float64(y) / a))
+	}
+	if x != img.Rect.Dx() || y != img.Rect.Dy() {
+		img = img.Resize(x, y, Nearest)
+	}
+	return img.Encode(w, typ)
+}
+
+// span returns the range of the n source pixels covered by destination pixel
+// i of m destination pixels.
+func span(i, m, n int) (int, int) {
+	return i * n / m, max(i*n/m+1, ((i+1)*n+m-1)/m)
+}
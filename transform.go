@@ -0,0 +1,81 @@
+package blocked
+
+import (
+	"image"
+)
+
+// FlipH returns a copy of the bitmap flipped horizontally.
+func (img Bitmap) FlipH() Bitmap {
+	r := img.Rect
+	return img.transform(false, func(x, y int) (int, int) {
+		return r.Min.X + r.Max.X - 1 - x, y
+	})
+}
+
+// FlipV returns a copy of the bitmap flipped vertically.
+func (img Bitmap) FlipV() Bitmap {
+	r := img.Rect
+	return img.transform(false, func(x, y int) (int, int) {
+		return x, r.Min.Y + r.Max.Y - 1 - y
+	})
+}
+
+// Rotate90 returns a copy of the bitmap rotated 90 degrees clockwise. The
+// returned bitmap's bounds are the bitmap's transposed bounds.
+func (img Bitmap) Rotate90() Bitmap {
+	r := img.Rect
+	return img.transform(true, func(x, y int) (int, int) {
+		return r.Min.Y + r.Max.Y - 1 - y, x
+	})
+}
+
+// Rotate180 returns a copy of the bitmap rotated 180 degrees.
+func (img Bitmap) Rotate180() Bitmap {
+	r := img.Rect
+	return img.transform(false, func(x, y int) (int, int) {
+		return r.Min.X + r.Max.X - 1 - x, r.Min.Y + r.Max.Y - 1 - y
+	})
+}
+
+// Rotate270 returns a copy of the bitmap rotated 270 degrees clockwise (90
+// degrees counterclockwise). The returned bitmap's bounds are the bitmap's
+// transposed bounds.
+func (img Bitmap) Rotate270() Bitmap {
+	r := img.Rect
+	return img.transform(true, func(x, y int) (int, int) {
+		return y, r.Min.X + r.Max.X - 1 - x
+	})
+}
+
+// Transpose returns a copy of the bitmap transposed, swapping the x and y
+// axes, such as for converting column-major bits to row-major bits.
+func (img Bitmap) Transpose() Bitmap {
+	return img.transform(true, func(x, y int) (int, int) {
+		return y, x
+	})
+}
+
+// transform returns a copy of the bitmap, where f maps the bitmap's points to
+// the copy's points. When transposed, the copy's bounds and scale are the
+// bitmap's transposed bounds and scale.
+func (img Bitmap) transform(transposed bool, f func(int, int) (int, int)) Bitmap {
+	dst := img.blank(img.Rect)
+	if transposed {
+		dst = img.blank(transpose(img.Rect))
+		dst.ScaleWidth, dst.ScaleHeight = img.ScaleHeight, img.ScaleWidth
+	}
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if img.Get(x, y) {
+				i, j := f(x, y)
+				dst.Set(i, j, true)
+			}
+		}
+	}
+	return dst
+}
+
+// transpose returns r with the x and y axes swapped.
+func transpose(r image.Rectangle) image.Rectangle {
+	return image.Rect(r.Min.Y, r.Min.X, r.Max.Y, r.Max.X)
+}